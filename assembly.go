@@ -2,12 +2,14 @@ package transloadit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -25,6 +27,26 @@ type Assembly struct {
 	// Assembly Instructions to allow customizing steps on a per-assembly basis.
 	// See https://transloadit.com/docs/topics/assembly-instructions/#assembly-variables
 	Fields map[string]interface{}
+	// UseTus specifies whether the files should be uploaded using the
+	// resumable tus protocol instead of a single multipart request. The
+	// assembly is created first and afterwards each file is sent in chunks to
+	// the returned tus endpoint, allowing interrupted uploads to be resumed
+	// from the last acknowledged offset.
+	// See https://transloadit.com/docs/topics/resumable-uploads/
+	UseTus bool
+	// TusChunkSize specifies the maximum number of bytes which are sent in a
+	// single tus PATCH request and buffered in memory while doing so. Defaults
+	// to 5MB if left unset.
+	TusChunkSize int64
+	// TusRetryDelays specifies how long to wait before each retry of a failed
+	// tus request. The number of entries determines the maximum number of
+	// retries. Defaults to 0s, 1s, 3s and 5s if left unset.
+	TusRetryDelays []time.Duration
+	// OnProgress is invoked while the files are uploaded by StartAssembly,
+	// reporting the bytes sent per file and in total. The expected sizes are
+	// known for files added using AddFile and readers providing a Len method.
+	// Progress is only reported for multipart uploads, not if UseTus is set.
+	OnProgress ProgressFunc
 
 	steps   map[string]map[string]interface{}
 	readers []*upload
@@ -34,6 +56,35 @@ type upload struct {
 	Field  string
 	Name   string
 	Reader io.ReadCloser
+
+	closeOnce sync.Once
+}
+
+// close closes the reader unless it has been closed already. It may be
+// invoked concurrently with a pending Read to abort it.
+func (upload *upload) close() {
+	upload.closeOnce.Do(func() {
+		upload.Reader.Close()
+	})
+}
+
+// UploadError is returned by StartAssembly if a file could not be read while
+// it was uploaded.
+type UploadError struct {
+	// Field and Name identify the file as passed to AddReader or AddFile.
+	Field string
+	Name  string
+	Err   error
+}
+
+// Error returns a formatted message describing the error.
+func (err UploadError) Error() string {
+	return fmt.Sprintf("failed to upload %s (field %s): %s", err.Name, err.Field, err.Err)
+}
+
+// Unwrap returns the error which occurred while reading the file.
+func (err UploadError) Unwrap() error {
+	return err.Err
 }
 
 // AssemblyReplay contains instructions used for replaying assemblies.
@@ -81,6 +132,7 @@ type AssemblyInfo struct {
 	Ok      string `json:"ok"`
 	Error   string `json:"error"`
 	Message string `json:"message"`
+	Reason  string `json:"reason"`
 
 	AssemblyID             string                 `json:"assembly_id"`
 	ParentID               string                 `json:"parent_id"`
@@ -111,6 +163,8 @@ type AssemblyInfo struct {
 	Uploads                []*FileInfo            `json:"uploads"`
 	Results                map[string][]*FileInfo `json:"results"`
 	Params                 string                 `json:"params"`
+	TusURL                 string                 `json:"tus_url"`
+	UpdateStreamURL        string                 `json:"update_stream_url"`
 
 	// Since 7 March 2018, the user agent, IP and referer are no longer
 	// stored by Transloadit (see https://transloadit.com/blog/2018/03/gdpr/)
@@ -204,6 +258,10 @@ func (assembly *Assembly) AddStep(name string, details map[string]interface{}) {
 // out). It won't wait until the execution has finished and results are
 // available, which can be achieved using WaitForAssembly.
 //
+// All readers added to the assembly are closed once, even if the assembly
+// could not be started. If the context is cancelled, reading from the readers
+// stops and readers blocked in a Read are closed to abort it.
+//
 // When an error is returned you should also check AssemblyInfo.Error for more
 // information about the error sent by the Transloadit API:
 //
@@ -215,51 +273,100 @@ func (assembly *Assembly) AddStep(name string, details map[string]interface{}) {
 //		panic(err)
 //	}
 func (client *Client) StartAssembly(ctx context.Context, assembly Assembly) (*AssemblyInfo, error) {
-	req, err := assembly.makeRequest(ctx, client)
+	ctx, op := client.startOperation(ctx, "POST", "assemblies")
+	info, err := client.startAssembly(ctx, assembly)
+	if info != nil {
+		op.setAssemblyID(info.AssemblyID)
+	}
+	op.end(err)
+
+	return info, err
+}
+
+// startAssembly implements StartAssembly.
+func (client *Client) startAssembly(ctx context.Context, assembly Assembly) (*AssemblyInfo, error) {
+	if assembly.UseTus {
+		return client.startTusAssembly(ctx, assembly, assembly.fingerprints())
+	}
+
+	req, writeErr, err := assembly.makeRequest(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create assembly request: %s", err)
+		return nil, fmt.Errorf("failed to create assembly request: %w", err)
 	}
 
+	// The HTTP client only returns after it has stopped reading the body, so
+	// the upload must be aborted while the request is still running. Closing
+	// the readers unblocks a writer waiting in a Read.
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			req.Body.Close()
+			closeReaders(assembly.readers)
+		case <-stop:
+		}
+	}()
+
 	var info AssemblyInfo
-	// TODO: add context.Context
-	if err = client.doRequest(req, &info); err != nil {
+	err = client.doRequest(req, &info)
+	close(stop)
+	<-stopped
+
+	// Stop the writer if the request has ended early.
+	req.Body.Close()
+	cancelled := err != nil && ctx.Err() != nil
+	if cancelled {
+		closeReaders(assembly.readers)
+	}
+
+	// The writer's error is more precise than the one caused by the truncated
+	// body, unless it is a consequence of the cancellation.
+	if uploadErr := <-writeErr; uploadErr != nil && !cancelled {
+		return nil, uploadErr
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	if info.Error != "" {
-		return &info, fmt.Errorf("failed to create assembly: %s", info.Error)
+		return &info, fmt.Errorf("failed to create assembly: %w", newAssemblyError(&info))
 	}
 
-	return &info, err
+	return &info, nil
 }
 
-func (assembly *Assembly) makeRequest(ctx context.Context, client *Client) (*http.Request, error) {
+// makeRequest creates the multipart request for uploading the assembly. The
+// body is written by a separate goroutine, whose error is sent on the
+// returned channel once it has finished. Failures to read a file are
+// reported as UploadError.
+func (assembly *Assembly) makeRequest(ctx context.Context, client *Client) (*http.Request, <-chan error, error) {
 	// TODO: test with huge files
 	url := client.config.Endpoint + "/assemblies"
 	bodyReader, bodyWriter := io.Pipe()
 	multiWriter := multipart.NewWriter(bodyWriter)
 
-	options := make(map[string]interface{})
-
-	if len(assembly.steps) != 0 {
-		options["steps"] = assembly.steps
-	}
-
-	if len(assembly.Fields) != 0 {
-		options["fields"] = assembly.Fields
+	params, signature, err := client.sign(assembly.options())
+	if err != nil {
+		closeReaders(assembly.readers)
+		return nil, nil, fmt.Errorf("unable to create upload request: %w", err)
 	}
 
-	if assembly.TemplateID != "" {
-		options["template_id"] = assembly.TemplateID
+	// Create HTTP request
+	req, err := http.NewRequest("POST", url, bodyReader)
+	if err != nil {
+		closeReaders(assembly.readers)
+		return nil, nil, fmt.Errorf("unable to create upload request: %w", err)
 	}
 
-	if assembly.NotifyURL != "" {
-		options["notify_url"] = assembly.NotifyURL
-	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", multiWriter.FormDataContentType())
 
-	params, signature, err := client.sign(options)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create upload request: %s", err)
+	// The sizes must be determined before the goroutine starts reading.
+	var progress *uploadProgress
+	if assembly.OnProgress != nil {
+		progress = newUploadProgress(assembly.readers, assembly.OnProgress)
 	}
 
 	// All writes to the multipart.Writer multiWriter _must_ happen inside this
@@ -267,43 +374,122 @@ func (assembly *Assembly) makeRequest(ctx context.Context, client *Client) (*htt
 	// in-memory pipe. Therefore a write to the multipart.Writer will block until
 	// a corresponding read is happening from the HTTP request. The gist is that
 	// the writes and reads must not occur sequentially but in parallel.
+	writeErr := make(chan error, 1)
 	go func() {
-		defer bodyWriter.Close()
-		defer multiWriter.Close()
-		// Add additional keys and values
-
-		if err := multiWriter.WriteField("params", params); err != nil {
-			fmt.Println(fmt.Errorf("unable to write params field: %s", err))
-		}
-		if err := multiWriter.WriteField("signature", signature); err != nil {
-			fmt.Println(fmt.Errorf("unable to write signature field: %s", err))
+		err := assembly.writeMultipart(ctx, multiWriter, params, signature, progress)
+		// Aborting the pipe makes the HTTP request fail instead of sending a
+		// truncated body.
+		bodyWriter.CloseWithError(err)
+
+		// Failing writes are caused by the request no longer reading the
+		// body, which is reported by the request itself.
+		var uploadErr UploadError
+		if !errors.As(err, &uploadErr) {
+			err = nil
 		}
+		writeErr <- err
+	}()
 
-		// Add files to upload
-		for _, reader := range assembly.readers {
-			defer reader.Reader.Close()
+	return req, writeErr, nil
+}
 
-			part, err := multiWriter.CreateFormFile(reader.Field, reader.Name)
-			if err != nil {
-				fmt.Println(fmt.Errorf("unable to create form field: %s", err))
-			}
+// writeMultipart writes the params, signature and all files into the
+// multipart writer. Each reader is closed once it has been copied and the
+// remaining ones are closed if writing fails.
+func (assembly *Assembly) writeMultipart(ctx context.Context, multiWriter *multipart.Writer, params, signature string, progress *uploadProgress) error {
+	defer closeReaders(assembly.readers)
+
+	// Add additional keys and values
+	if err := multiWriter.WriteField("params", params); err != nil {
+		return fmt.Errorf("unable to write params field: %w", err)
+	}
+	if err := multiWriter.WriteField("signature", signature); err != nil {
+		return fmt.Errorf("unable to write signature field: %w", err)
+	}
+
+	// Add files to upload
+	for i, reader := range assembly.readers {
+		part, err := multiWriter.CreateFormFile(reader.Field, reader.Name)
+		if err != nil {
+			return fmt.Errorf("unable to create form field: %w", err)
+		}
 
-			if _, err := io.Copy(part, reader.Reader); err != nil {
-				fmt.Println(fmt.Errorf("unable to create upload request: %s", err))
+		var source io.Reader = reader.Reader
+		if progress != nil {
+			source = progress.reader(i, reader)
+		}
+		source = contextReader{ctx: ctx, reader: source}
+
+		// Read errors are distinguished from write errors, which occur if
+		// the request has stopped reading the body.
+		tracked := &readErrorReader{reader: source}
+		n, err := io.Copy(part, tracked)
+		operationFromContext(ctx).addUploaded(n)
+		if err != nil {
+			if tracked.err != nil {
+				return UploadError{Field: reader.Field, Name: reader.Name, Err: tracked.err}
 			}
+			return fmt.Errorf("unable to write form field: %w", err)
 		}
-	}()
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create upload request: %s", err)
+		// Release the file as soon as possible instead of keeping all of them
+		// open until the upload has finished.
+		reader.close()
 	}
 
-	req = req.WithContext(ctx)
-	req.Header.Set("Content-Type", multiWriter.FormDataContentType())
+	return multiWriter.Close()
+}
+
+// readErrorReader records the error returned by the underlying reader, other
+// than io.EOF.
+type readErrorReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *readErrorReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// contextReader stops reading once the context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// options returns the assembly instructions which are signed and sent to the
+// API when creating the assembly.
+func (assembly *Assembly) options() map[string]interface{} {
+	options := make(map[string]interface{})
+
+	if len(assembly.steps) != 0 {
+		options["steps"] = assembly.steps
+	}
+
+	if len(assembly.Fields) != 0 {
+		options["fields"] = assembly.Fields
+	}
+
+	if assembly.TemplateID != "" {
+		options["template_id"] = assembly.TemplateID
+	}
+
+	if assembly.NotifyURL != "" {
+		options["notify_url"] = assembly.NotifyURL
+	}
 
-	return req, nil
+	return options
 }
 
 // GetAssembly fetches the full assembly status from the provided URL.
@@ -369,7 +555,7 @@ func (client *Client) StartAssemblyReplay(ctx context.Context, assembly Assembly
 	}
 
 	if info.Error != "" {
-		return &info, fmt.Errorf("failed to start assembly replay: %s", info.Error)
+		return &info, fmt.Errorf("failed to start assembly replay: %w", newAssemblyError(&info))
 	}
 
 	return &info, nil
@@ -1,10 +1,21 @@
 package transloadit
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var assemblyURL string
@@ -264,3 +275,137 @@ func TestInteger_MarshalJSON(t *testing.T) {
 		t.Fatal("wrong default value for string")
 	}
 }
+
+// failingReader returns the data and afterwards the error instead of io.EOF.
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *failingReader) Close() error { return nil }
+
+func TestStartAssembly_UploadError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		writeJSON(w, map[string]string{"ok": "ASSEMBLY_UPLOADING"})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	readErr := errors.New("disk on fire")
+	assembly := NewAssembly()
+	assembly.AddReader("image", "ok.bin", sizedReader{bytes.NewReader(testPayload(100))})
+	assembly.AddReader("video", "broken.bin", &failingReader{data: testPayload(64 * 1024), err: readErr})
+
+	info, err := client.StartAssembly(ctx, assembly)
+	if info != nil {
+		t.Fatalf("unexpected assembly info %+v", info)
+	}
+
+	var uploadErr UploadError
+	if !errors.As(err, &uploadErr) {
+		t.Fatalf("expected UploadError, got %v", err)
+	}
+	if uploadErr.Field != "video" || uploadErr.Name != "broken.bin" {
+		t.Fatalf("wrong upload error %+v", uploadErr)
+	}
+	if !errors.Is(err, readErr) {
+		t.Fatalf("upload error should wrap the read error: %v", err)
+	}
+}
+
+// countingCloser counts how often it is closed and optionally invokes
+// onClose, for example to unblock a pending Read.
+type countingCloser struct {
+	io.Reader
+	closed  int32
+	onClose func()
+}
+
+func (c *countingCloser) Close() error {
+	if atomic.AddInt32(&c.closed, 1) == 1 && c.onClose != nil {
+		c.onClose()
+	}
+	return nil
+}
+
+func TestStartAssembly_Cancel(t *testing.T) {
+	// Not parallel, since the number of goroutines is compared.
+	before := runtime.NumGoroutine()
+
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Signal once the upload has started and keep reading until the
+		// client aborts the request.
+		var buf [1]byte
+		if _, err := r.Body.Read(buf[:]); err == nil {
+			close(received)
+		}
+		io.Copy(ioutil.Discard, r.Body)
+	}))
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	// The second reader blocks until it is closed and the third one is
+	// never reached.
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeWriter.Close()
+	readers := []*countingCloser{
+		{Reader: bytes.NewReader(testPayload(100))},
+		{Reader: pipeReader, onClose: func() { pipeReader.Close() }},
+		{Reader: bytes.NewReader(testPayload(100))},
+	}
+
+	assembly := NewAssembly()
+	for i, reader := range readers {
+		assembly.AddReader("file", fmt.Sprintf("%d.bin", i), reader)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	_, err := client.StartAssembly(ctx, assembly)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+
+	for i, reader := range readers {
+		if closed := atomic.LoadInt32(&reader.closed); closed != 1 {
+			t.Errorf("reader %d closed %d times", i, closed)
+		}
+	}
+
+	server.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("%d goroutines leaked:\n%s", runtime.NumGoroutine()-before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,62 @@
+package transloadit
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// invalidSignatureCode is the error code returned by the API if it does not
+// accept a request's signature. There is no dedicated code for an
+// auth.expires parameter which lies in the past, such requests are rejected
+// as INVALID_SIGNATURE as well.
+// See https://transloadit.com/docs/topics/signature-authentication/
+const invalidSignatureCode = "INVALID_SIGNATURE"
+
+// clock provides the current time for signatures. It is corrected by the
+// offset to the API's clock once a signature has been rejected as expired.
+// It is shared by all copies of a Client.
+type clock struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func newClock(now func() time.Time) *clock {
+	if now == nil {
+		now = time.Now
+	}
+	return &clock{now: now}
+}
+
+// Now returns the current time, corrected by the offset to the API's clock.
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now().Add(c.offset)
+}
+
+// expired reports whether a signature created at signedAt, which is valid for
+// the provided TTL, has expired according to the API's clock. The API's time
+// is derived from the Date header of the response. If the signature has
+// expired, the clock is corrected by the offset to the API's clock.
+func (c *clock) expired(header http.Header, signedAt time.Time, ttl time.Duration) bool {
+	serverTime, err := http.ParseTime(header.Get("Date"))
+	if err != nil || !serverTime.After(signedAt.Add(ttl)) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = serverTime.Sub(c.now())
+	return true
+}
+
+// isSignatureExpired reports whether the API rejected the request since its
+// signature has expired.
+func isSignatureExpired(err error) bool {
+	var reqErr RequestError
+	return errors.As(err, &reqErr) && reqErr.signatureExpired
+}
@@ -0,0 +1,168 @@
+package transloadit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+// recordExpiries adds a middleware to the configuration which records the
+// auth.expires parameter of each request.
+func recordExpiries(config *transloadit.Config) func() []string {
+	var mu sync.Mutex
+	var expiries []string
+
+	config.Middleware = append(config.Middleware, func(next http.RoundTripper) http.RoundTripper {
+		return transloadit.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var params struct {
+				Auth struct {
+					Expires string `json:"expires"`
+				} `json:"auth"`
+			}
+			json.Unmarshal([]byte(req.URL.Query().Get("params")), &params)
+
+			mu.Lock()
+			expiries = append(expiries, params.Auth.Expires)
+			mu.Unlock()
+			return next.RoundTrip(req)
+		})
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), expiries...)
+	}
+}
+
+func TestClock_SignatureExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	// The local clock is behind by two hours, so all signatures have expired
+	// according to the server.
+	config := server.Config()
+	config.Clock = func() time.Time {
+		return time.Now().Add(-2 * time.Hour)
+	}
+	expiries := recordExpiries(&config)
+	client := transloadit.NewClient(config)
+
+	if _, err := client.ListTemplates(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(expiries()) != 2 {
+		t.Fatalf("expected one retry, got %d requests", len(expiries()))
+	}
+
+	// The corrected clock is used for all following requests.
+	if _, err := client.ListTemplates(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(expiries()) != 3 {
+		t.Fatalf("expected no retry, got %d requests", len(expiries()))
+	}
+
+	expires, err := time.Parse("2006/01/02 15:04:05-07:00", expiries()[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset := expires.Sub(time.Now().Add(time.Hour)); offset < -time.Minute || offset > time.Minute {
+		t.Fatalf("clock has not been corrected, offset is %s", offset)
+	}
+}
+
+func TestClock_InvalidSignature(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	// Signatures which have not expired are not retried.
+	config := server.Config()
+	config.AuthSecret = "wrong"
+	expiries := recordExpiries(&config)
+	client := transloadit.NewClient(config)
+
+	if _, err := client.ListTemplates(ctx, nil); !errors.Is(err, transloadit.ErrSignatureInvalid) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(expiries()) != 1 {
+		t.Fatalf("expected no retry, got %d requests", len(expiries()))
+	}
+}
+
+func TestClock_SignatureTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	for _, test := range []struct {
+		ttl      time.Duration
+		expected time.Duration
+	}{
+		// A negative TTL is replaced by the default one hour.
+		{-time.Minute, time.Hour},
+		{5 * time.Minute, 5 * time.Minute},
+	} {
+		config := server.Config()
+		config.SignatureTTL = test.ttl
+		expiries := recordExpiries(&config)
+		client := transloadit.NewClient(config)
+
+		if _, err := client.ListTemplates(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		expires, err := time.Parse("2006/01/02 15:04:05-07:00", expiries()[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ttl := time.Until(expires); ttl < test.expected-time.Minute || ttl > test.expected {
+			t.Errorf("TTL %s: wrong expiry %s", test.ttl, expires)
+		}
+	}
+}
+
+func TestClock_RetryOnce(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	// The server keeps rejecting the signatures, although the clock has been
+	// corrected.
+	server.InjectFault(transloadittest.Fault{
+		StatusCode: http.StatusUnauthorized,
+		Code:       "INVALID_SIGNATURE",
+		Message:    "The signature has expired.",
+	})
+
+	config := server.Config()
+	config.Clock = func() time.Time {
+		return time.Now().Add(-2 * time.Hour)
+	}
+	expiries := recordExpiries(&config)
+	client := transloadit.NewClient(config)
+
+	if _, err := client.ListTemplates(ctx, nil); !errors.Is(err, transloadit.ErrSignatureInvalid) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(expiries()) != 2 {
+		t.Fatalf("expected exactly one retry, got %d requests", len(expiries()))
+	}
+}
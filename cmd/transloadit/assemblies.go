@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+var assemblyCommands = map[string]command{
+	"create": {"[flags] [files...]", "create an assembly and upload files", createAssembly},
+	"get":    {"[flags] <id or url>", "show an assembly's status", getAssembly},
+	"cancel": {"[flags] <id or url>", "cancel an assembly", cancelAssembly},
+	"replay": {"[flags] <id or url>", "replay an assembly", replayAssembly},
+	"list":   {"[flags]", "list assemblies", listAssemblies},
+	"wait":   {"[flags] <id or url>", "wait until an assembly has finished", waitAssembly},
+}
+
+func createAssembly(c *cli, args []string) error {
+	flags := c.flags()
+	templateID := flags.String("template", "", "`id` of the template to use")
+	stepsFile := flags.String("steps", "", "JSON `file` containing the steps")
+	notifyURL := flags.String("notify-url", "", "`url` to notify once the assembly has finished")
+	fieldName := flags.String("field", "file", "form field `name` for the uploaded files")
+	tus := flags.Bool("tus", false, "upload the files using the resumable tus protocol")
+	wait := flags.Bool("wait", false, "wait until the assembly has finished")
+	var fields keyValues
+	flags.Var(&fields, "var", "assembly variable as `key=value`, may be repeated")
+	if err := c.parse(flags, args, 0, -1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	assembly := transloadit.NewAssembly()
+	assembly.TemplateID = *templateID
+	assembly.NotifyURL = *notifyURL
+	assembly.UseTus = *tus
+	for _, field := range fields {
+		assembly.Fields[field[0]] = field[1]
+	}
+
+	if *stepsFile != "" {
+		var steps map[string]map[string]interface{}
+		if err := readJSON(*stepsFile, &steps); err != nil {
+			return err
+		}
+		for name, step := range steps {
+			assembly.AddStep(name, step)
+		}
+	}
+
+	for _, path := range flags.Args() {
+		if err := assembly.AddFile(*fieldName, path); err != nil {
+			return err
+		}
+	}
+
+	info, err := client.StartAssembly(c.ctx, assembly)
+	if err != nil {
+		return err
+	}
+
+	if *wait {
+		if info, err = client.WaitForAssembly(c.ctx, info); err != nil {
+			return err
+		}
+	}
+
+	return c.printAssembly(info)
+}
+
+func getAssembly(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := client.GetAssembly(c.ctx, c.assemblyURL(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+	return c.printAssembly(info)
+}
+
+func cancelAssembly(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := client.CancelAssembly(c.ctx, c.assemblyURL(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+	return c.printAssembly(info)
+}
+
+func replayAssembly(c *cli, args []string) error {
+	flags := c.flags()
+	notifyURL := flags.String("notify-url", "", "`url` to notify instead of the original one")
+	reparse := flags.Bool("reparse-template", false, "fetch the template again before replaying")
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	replay := transloadit.NewAssemblyReplay(c.assemblyURL(flags.Arg(0)))
+	replay.NotifyURL = *notifyURL
+	replay.ReparseTemplate = *reparse
+
+	info, err := client.StartAssemblyReplay(c.ctx, replay)
+	if err != nil {
+		return err
+	}
+	return c.printAssembly(info)
+}
+
+func listAssemblies(c *cli, args []string) error {
+	flags := c.flags()
+	options, all := listFlags(flags)
+	if err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	var items []*transloadit.AssemblyListItem
+	if *all {
+		it := client.Assemblies(c.ctx, options)
+		for it.Next() {
+			items = append(items, it.Item())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	} else {
+		list, err := client.ListAssemblies(c.ctx, options)
+		if err != nil {
+			return err
+		}
+		items = list.Assemblies
+	}
+
+	rows := [][]string{{"ID", "STATUS", "CREATED", "TEMPLATE"}}
+	for _, item := range items {
+		rows = append(rows, []string{item.AssemblyID, status(item.Ok, item.Error), item.Created.Format("2006-01-02 15:04:05"), item.TemplateID})
+	}
+	return c.print(items, rows)
+}
+
+func waitAssembly(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := client.WaitForAssembly(c.ctx, &transloadit.AssemblyInfo{
+		AssemblySSLURL: c.assemblyURL(flags.Arg(0)),
+	})
+	if err != nil {
+		return err
+	}
+	return c.printAssembly(info)
+}
+
+// printAssembly prints the assembly status and its results.
+func (c *cli) printAssembly(info *transloadit.AssemblyInfo) error {
+	rows := [][]string{
+		{"ID", info.AssemblyID},
+		{"STATUS", status(info.Ok, info.Error)},
+		{"URL", info.AssemblySSLURL},
+	}
+	if info.Message != "" {
+		rows = append(rows, []string{"MESSAGE", info.Message})
+	}
+
+	steps := make([]string, 0, len(info.Results))
+	for step := range info.Results {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	for _, step := range steps {
+		for _, file := range info.Results[step] {
+			rows = append(rows, []string{"RESULT", step + "\t" + file.SSLURL})
+		}
+	}
+	return c.print(info, rows)
+}
+
+// assemblyURL returns the status URL for an assembly ID. URLs are returned
+// unchanged.
+func (c *cli) assemblyURL(idOrURL string) string {
+	if strings.Contains(idOrURL, "://") {
+		return idOrURL
+	}
+	return fmt.Sprintf("%s/assemblies/%s", c.endpoint, idOrURL)
+}
+
+// status returns the error if present or the ok status otherwise.
+func status(ok, err string) string {
+	if err != "" {
+		return err
+	}
+	return ok
+}
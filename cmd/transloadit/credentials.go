@@ -0,0 +1,146 @@
+package main
+
+import (
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+var credentialCommands = map[string]command{
+	"create": {"[flags] <file>", "create template credentials from a JSON file", createCredential},
+	"get":    {"[flags] <id>", "show template credentials", getCredential},
+	"update": {"[flags] <id> <file>", "replace template credentials with a JSON file", updateCredential},
+	"delete": {"[flags] <id>", "delete template credentials", deleteCredential},
+	"list":   {"[flags]", "list template credentials", listCredentials},
+}
+
+// The credential files contain the name, type and content:
+//
+//	{"name": "my-s3", "type": "s3", "content": {"bucket": "..."}}
+
+func createCredential(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	credential := transloadit.NewTemplateCredential()
+	if err := readJSON(flags.Arg(0), &credential); err != nil {
+		return err
+	}
+
+	id, err := client.CreateTemplateCredential(c.ctx, credential)
+	if err != nil {
+		return err
+	}
+	credential.ID = id
+
+	return c.printCredential(credential)
+}
+
+func getCredential(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	credential, err := client.GetTemplateCredential(c.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printCredential(credential)
+}
+
+func updateCredential(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 2, 2); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	credential := transloadit.NewTemplateCredential()
+	if err := readJSON(flags.Arg(1), &credential); err != nil {
+		return err
+	}
+	credential.ID = flags.Arg(0)
+
+	if err := client.UpdateTemplateCredential(c.ctx, credential.ID, credential); err != nil {
+		return err
+	}
+	return c.printCredential(credential)
+}
+
+func deleteCredential(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.DeleteTemplateCredential(c.ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+	return c.print(map[string]string{"id": flags.Arg(0), "ok": "TEMPLATE_CREDENTIALS_DELETED"}, [][]string{{"DELETED", flags.Arg(0)}})
+}
+
+func listCredentials(c *cli, args []string) error {
+	flags := c.flags()
+	options, all := listFlags(flags)
+	if err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	var credentials []transloadit.TemplateCredential
+	if *all {
+		it := client.TemplateCredentials(c.ctx, options)
+		for it.Next() {
+			credentials = append(credentials, it.Item())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	} else {
+		list, err := client.ListTemplateCredential(c.ctx, options)
+		if err != nil {
+			return err
+		}
+		credentials = list.TemplateCredential
+	}
+
+	rows := [][]string{{"ID", "NAME", "TYPE", "MODIFIED"}}
+	for _, credential := range credentials {
+		rows = append(rows, []string{credential.ID, credential.Name, credential.Type, credential.Modified})
+	}
+	return c.print(credentials, rows)
+}
+
+// printCredential prints the credentials without their content, which
+// usually contains secrets, unless JSON output is selected.
+func (c *cli) printCredential(credential transloadit.TemplateCredential) error {
+	return c.print(credential, [][]string{
+		{"ID", credential.ID},
+		{"NAME", credential.Name},
+		{"TYPE", credential.Type},
+	})
+}
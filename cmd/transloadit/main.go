@@ -0,0 +1,259 @@
+// Command transloadit is a command-line client for the Transloadit API built
+// on top of the Go SDK. The credentials are read from the TRANSLOADIT_KEY
+// and TRANSLOADIT_SECRET environment variables. TRANSLOADIT_ENDPOINT can be
+// used to override the default API endpoint.
+//
+// Usage:
+//
+//	transloadit <resource> <action> [flags] [arguments]
+//
+// Run transloadit without arguments to list all commands. Every command
+// accepts -format json or -format table (the default) to select the output.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+// command is a single action of a resource, such as "assemblies create".
+type command struct {
+	usage       string
+	description string
+	run         func(c *cli, args []string) error
+}
+
+// commands maps each resource to its actions.
+var commands = map[string]map[string]command{
+	"assemblies":    assemblyCommands,
+	"templates":     templateCommands,
+	"credentials":   credentialCommands,
+	"notifications": notificationCommands,
+	"smartcdn":      smartCDNCommands,
+}
+
+// errUsage is returned if the command line is invalid. The usage has already
+// been printed in this case.
+var errUsage = errors.New("invalid usage")
+
+// cli holds the state shared by all commands.
+type cli struct {
+	ctx    context.Context
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	name     string
+	args     string
+	format   string
+	endpoint string
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	c := &cli{
+		ctx:    ctx,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		getenv: os.Getenv,
+	}
+	if err := c.run(os.Args[1:]); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "transloadit:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes the command selected by args.
+func (c *cli) run(args []string) error {
+	if len(args) < 2 {
+		c.usage()
+		return errUsage
+	}
+
+	actions, ok := commands[args[0]]
+	if !ok {
+		c.usage()
+		return errUsage
+	}
+	cmd, ok := actions[args[1]]
+	if !ok {
+		c.usage()
+		return errUsage
+	}
+
+	c.name = args[0] + " " + args[1]
+	c.args = cmd.usage
+	return cmd.run(c, args[2:])
+}
+
+// usage prints all available commands.
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: transloadit <resource> <action> [flags] [arguments]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+
+	resources := make([]string, 0, len(commands))
+	for resource := range commands {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	w := tabwriter.NewWriter(c.stderr, 0, 4, 2, ' ', 0)
+	for _, resource := range resources {
+		actions := make([]string, 0, len(commands[resource]))
+		for action := range commands[resource] {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+
+		for _, action := range actions {
+			cmd := commands[resource][action]
+			fmt.Fprintf(w, "  %s %s %s\t%s\n", resource, action, cmd.usage, cmd.description)
+		}
+	}
+	w.Flush()
+}
+
+// flags returns a flag set for the current command, which includes the
+// -format flag.
+func (c *cli) flags() *flag.FlagSet {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.StringVar(&c.format, "format", "table", "output `format`, either json or table")
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: transloadit %s %s\n", c.name, c.args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the flags and checks the number of remaining arguments, which
+// must be between min and max (or unlimited if max is negative).
+func (c *cli) parse(flags *flag.FlagSet, args []string, min, max int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if c.format != "json" && c.format != "table" {
+		fmt.Fprintf(c.stderr, "invalid format %q\n", c.format)
+		flags.Usage()
+		return errUsage
+	}
+	if n := flags.NArg(); n < min || (max >= 0 && n > max) {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// client creates a client using the credentials from the environment.
+func (c *cli) client() (transloadit.Client, error) {
+	config := transloadit.DefaultConfig
+	config.AuthKey = c.getenv("TRANSLOADIT_KEY")
+	config.AuthSecret = c.getenv("TRANSLOADIT_SECRET")
+	if endpoint := c.getenv("TRANSLOADIT_ENDPOINT"); endpoint != "" {
+		config.Endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+
+	if config.AuthKey == "" || config.AuthSecret == "" {
+		return transloadit.Client{}, errors.New("TRANSLOADIT_KEY and TRANSLOADIT_SECRET must be set")
+	}
+	c.endpoint = config.Endpoint
+	return transloadit.NewClient(config), nil
+}
+
+// print writes value as JSON or the rows as table, depending on the selected
+// format. The first row contains the column headers.
+func (c *cli) print(value interface{}, rows [][]string) error {
+	if c.format == "json" {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// listFlags registers the flags for paginated lists.
+func listFlags(flags *flag.FlagSet) (*transloadit.ListOptions, *bool) {
+	options := &transloadit.ListOptions{}
+	flags.IntVar(&options.Page, "page", 1, "`page` to list")
+	flags.IntVar(&options.PageSize, "pagesize", 50, "number of items per page")
+	all := flags.Bool("all", false, "list all pages")
+	return options, all
+}
+
+// readJSON decodes the JSON file at path into value. The path - denotes
+// standard input.
+func readJSON(path string, value interface{}) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// keyValues is a repeatable flag of key=value pairs.
+type keyValues [][2]string
+
+func (kv *keyValues) String() string {
+	pairs := make([]string, len(*kv))
+	for i, pair := range *kv {
+		pairs[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (kv *keyValues) Set(value string) error {
+	i := strings.IndexByte(value, '=')
+	if i < 1 {
+		return fmt.Errorf("expected key=value but got %q", value)
+	}
+	*kv = append(*kv, [2]string{value[:i], value[i+1:]})
+	return nil
+}
+
+// jsonString returns the compact JSON encoding of value.
+func jsonString(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+// runCLI executes the command line against the server and returns the
+// output written to stdout.
+func runCLI(t *testing.T, server *transloadittest.Server, args ...string) (string, error) {
+	t.Helper()
+
+	env := map[string]string{
+		"TRANSLOADIT_KEY":      "key",
+		"TRANSLOADIT_SECRET":   "secret",
+		"TRANSLOADIT_ENDPOINT": server.URL,
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		ctx:    context.Background(),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string { return env[key] },
+	}
+	err := c.run(args)
+	return stdout.String(), err
+}
+
+func TestAssemblies(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "transloadit-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	steps := filepath.Join(dir, "steps.json")
+	if err := ioutil.WriteFile(steps, []byte(`{"resize":{"robot":"/image/resize","use":":original"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, server, "assemblies", "create", "-format", "json", "-steps", steps, "-var", "size=75", "../../fixtures/lol_cat.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info transloadit.AssemblyInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Uploads) != 1 || info.Uploads[0].Field != "file" || info.Fields["size"] != "75" {
+		t.Fatalf("wrong assembly %+v", info)
+	}
+
+	out, err = runCLI(t, server, "assemblies", "wait", info.AssemblyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "ASSEMBLY_COMPLETED") || !strings.Contains(out, info.AssemblyID) {
+		t.Fatalf("wrong output %q", out)
+	}
+
+	if _, err := runCLI(t, server, "assemblies", "replay", info.AssemblySSLURL); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runCLI(t, server, "assemblies", "list", "-all", "-pagesize", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("wrong list %q", out)
+	}
+
+	server.SetTransitions(transloadittest.StateExecuting)
+	if _, err := runCLI(t, server, "notifications", "replay", info.AssemblyID, "-notify-url", "https://example.com"); err == nil {
+		t.Fatal("flags after arguments should be rejected")
+	}
+	if _, err := runCLI(t, server, "notifications", "replay", "-notify-url", "https://example.com", info.AssemblyID); err != nil {
+		t.Fatal(err)
+	}
+	if replays := server.NotificationReplays(); len(replays) != 1 || replays[0].NotifyURL != "https://example.com" {
+		t.Fatalf("wrong replays %+v", replays)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "transloadit-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "thumbnails.json")
+	if err := ioutil.WriteFile(file, []byte(`{"steps":{"resize":{"robot":"/image/resize"}},"notify_url":"https://example.com"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, server, "templates", "create", "-format", "json", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var template transloadit.Template
+	if err := json.Unmarshal([]byte(out), &template); err != nil {
+		t.Fatal(err)
+	}
+	if template.ID == "" || template.Name != "thumbnails" {
+		t.Fatalf("wrong template %+v", template)
+	}
+
+	if _, err := runCLI(t, server, "templates", "update", "-name", "renamed", template.ID, file); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runCLI(t, server, "templates", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "renamed") {
+		t.Fatalf("wrong list %q", out)
+	}
+
+	if _, err := runCLI(t, server, "templates", "delete", template.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, server, "templates", "get", template.ID); err == nil {
+		t.Fatal("expected an error for deleted templates")
+	}
+}
+
+func TestTemplatesUpdate_SignatureAuth(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "transloadit-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "thumbnails.json")
+	if err := ioutil.WriteFile(file, []byte(`{"steps":{"resize":{"robot":"/image/resize"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := transloadit.NewClient(transloadit.Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	template := transloadit.NewTemplate()
+	template.Name = "thumbnails"
+	template.RequireSignatureAuth = true
+	id, err := client.CreateTemplate(context.Background(), template)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requireSignatureAuth := func() bool {
+		t.Helper()
+		fetched, err := client.GetTemplate(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fetched.RequireSignatureAuth
+	}
+
+	// Updating the content keeps signature authentication enabled.
+	if _, err := runCLI(t, server, "templates", "update", id, file); err != nil {
+		t.Fatal(err)
+	}
+	if !requireSignatureAuth() {
+		t.Fatal("signature authentication was disabled")
+	}
+
+	if _, err := runCLI(t, server, "templates", "update", "-require-signature-auth=false", id, file); err != nil {
+		t.Fatal(err)
+	}
+	if requireSignatureAuth() {
+		t.Fatal("signature authentication was not disabled")
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "transloadit-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "s3.json")
+	if err := ioutil.WriteFile(file, []byte(`{"name":"my-s3","type":"s3","content":{"bucket":"foo"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, server, "credentials", "create", "-format", "json", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var credential transloadit.TemplateCredential
+	if err := json.Unmarshal([]byte(out), &credential); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runCLI(t, server, "credentials", "list", "-all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, credential.ID) || !strings.Contains(out, "my-s3") {
+		t.Fatalf("wrong list %q", out)
+	}
+
+	if _, err := runCLI(t, server, "credentials", "delete", credential.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSmartCDNSign(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	out, err := runCLI(t, server, "smartcdn", "sign", "-param", "width=100", "workspace", "template", "input.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Host != "workspace.tlcdn.com" || query.Get("width") != "100" || query.Get("auth_key") != "key" || !strings.HasPrefix(query.Get("sig"), "sha256:") {
+		t.Fatalf("wrong URL %s", u)
+	}
+
+	out, err = runCLI(t, server, "smartcdn", "sign", "-host", "cdn.example.com", "-width", "100", "-output-format", "webp", "workspace", "template", "input.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err = url.Parse(strings.TrimSpace(out)); err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "cdn.example.com" || u.Query().Get("format") != "webp" {
+		t.Fatalf("wrong URL %s", u)
+	}
+
+	if _, err := runCLI(t, server, "smartcdn", "sign", "-quality", "101", "workspace", "template", "input.jpg"); err == nil {
+		t.Fatal("expected error for invalid quality")
+	}
+	if _, err := runCLI(t, server, "smartcdn", "sign", "work space", "template", "input.jpg"); err == nil {
+		t.Fatal("expected error for invalid workspace")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	for _, args := range [][]string{
+		{},
+		{"assemblies"},
+		{"assemblies", "unknown"},
+		{"assemblies", "get"},
+		{"assemblies", "list", "-format", "yaml"},
+	} {
+		if _, err := runCLI(t, server, args...); err != errUsage {
+			t.Errorf("expected usage error for %q, got %v", args, err)
+		}
+	}
+
+	c := &cli{
+		ctx:    context.Background(),
+		stdout: ioutil.Discard,
+		stderr: ioutil.Discard,
+		getenv: func(string) string { return "" },
+	}
+	if err := c.run([]string{"assemblies", "list"}); err == nil || err == errUsage {
+		t.Fatalf("expected missing credentials error, got %v", err)
+	}
+}
+
+func TestTemplatesSync(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "transloadit-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "thumbnails.json"), []byte(`{"steps":{"resize":{"robot":"/image/resize"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, server, "templates", "sync", "-dry-run", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "create") || !strings.Contains(out, `+ steps.resize: {"robot":"/image/resize"}`) {
+		t.Fatalf("wrong output %q", out)
+	}
+
+	out, err = runCLI(t, server, "templates", "sync", "-format", "json", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []transloadit.TemplateChange
+	if err := json.Unmarshal([]byte(out), &changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Action != transloadit.TemplateCreate || changes[0].ID == "" {
+		t.Fatalf("wrong changes %+v", changes)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+var notificationCommands = map[string]command{
+	"replay": {"[flags] <assembly id>", "send an assembly's notification again", replayNotification},
+}
+
+func replayNotification(c *cli, args []string) error {
+	flags := c.flags()
+	notifyURL := flags.String("notify-url", "", "`url` to notify instead of the original one")
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.ReplayNotification(c.ctx, flags.Arg(0), *notifyURL); err != nil {
+		return err
+	}
+	return c.print(map[string]string{"assembly_id": flags.Arg(0), "ok": "ASSEMBLY_NOTIFICATION_REPLAYED"}, [][]string{{"REPLAYED", flags.Arg(0)}})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+)
+
+var smartCDNCommands = map[string]command{
+	"sign": {"[flags] <workspace> <template> <input>", "create a signed Smart CDN URL", signSmartCDN},
+}
+
+func signSmartCDN(c *cli, args []string) error {
+	flags := c.flags()
+	expiresIn := flags.Duration("expires-in", time.Hour, "`duration` after which the signature expires")
+	host := flags.String("host", "", "custom `host` used instead of <workspace>.tlcdn.com")
+	width := flags.Int("width", 0, "resize to `pixels` wide")
+	height := flags.Int("height", 0, "resize to `pixels` high")
+	format := flags.String("output-format", "", "image `format`, for example webp")
+	quality := flags.Int("quality", 0, "output `quality` between 1 and 100")
+	var params keyValues
+	flags.Var(&params, "param", "additional query parameter as `key=value`, may be repeated")
+	if err := c.parse(flags, args, 3, 3); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	builder := client.NewSmartCDNUrlBuilder(flags.Arg(0), flags.Arg(1)).ExpiresIn(*expiresIn)
+	if *host != "" {
+		builder = builder.Host(*host)
+	}
+	if *width != 0 {
+		builder = builder.Width(*width)
+	}
+	if *height != 0 {
+		builder = builder.Height(*height)
+	}
+	if *format != "" {
+		builder = builder.Format(*format)
+	}
+	if *quality != 0 {
+		builder = builder.Quality(*quality)
+	}
+	for _, param := range params {
+		builder = builder.Param(param[0], param[1])
+	}
+
+	signedURL, err := builder.URL(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	return c.print(map[string]string{"url": signedURL}, [][]string{{signedURL}})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+var templateCommands = map[string]command{
+	"create": {"[flags] <file>", "create a template from a JSON file", createTemplate},
+	"get":    {"[flags] <id>", "show a template", getTemplate},
+	"update": {"[flags] <id> <file>", "replace a template's content with a JSON file", updateTemplate},
+	"delete": {"[flags] <id>", "delete a template", deleteTemplate},
+	"list":   {"[flags]", "list templates", listTemplates},
+	"sync":   {"[flags] <directory>", "create and update templates from JSON files", syncTemplates},
+}
+
+func createTemplate(c *cli, args []string) error {
+	flags := c.flags()
+	name := flags.String("name", "", "template `name`, defaults to the file name")
+	requireSignature := flags.Bool("require-signature-auth", false, "require signature authentication")
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	template := transloadit.NewTemplate()
+	if err := readJSON(flags.Arg(0), &template.Content); err != nil {
+		return err
+	}
+	template.Name = *name
+	if template.Name == "" {
+		template.Name = templateName(flags.Arg(0))
+	}
+	template.RequireSignatureAuth = *requireSignature
+
+	id, err := client.CreateTemplate(c.ctx, template)
+	if err != nil {
+		return err
+	}
+	template.ID = id
+
+	return c.printTemplate(template)
+}
+
+func getTemplate(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	template, err := client.GetTemplate(c.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printTemplate(template)
+}
+
+func updateTemplate(c *cli, args []string) error {
+	flags := c.flags()
+	name := flags.String("name", "", "new template `name`, keeps the current one if empty")
+	requireSignature := flags.Bool("require-signature-auth", false, "require signature authentication, keeps the current setting if omitted")
+	if err := c.parse(flags, args, 2, 2); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	template, err := client.GetTemplate(c.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	template.Content = transloadit.TemplateContent{}
+	if err := readJSON(flags.Arg(1), &template.Content); err != nil {
+		return err
+	}
+	if *name != "" {
+		template.Name = *name
+	}
+	// The setting is only changed if requested, so that updating the content
+	// does not disable signature authentication.
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "require-signature-auth" {
+			template.RequireSignatureAuth = *requireSignature
+		}
+	})
+
+	if err := client.UpdateTemplate(c.ctx, template.ID, template); err != nil {
+		return err
+	}
+	return c.printTemplate(template)
+}
+
+func deleteTemplate(c *cli, args []string) error {
+	flags := c.flags()
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.DeleteTemplate(c.ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+	return c.print(map[string]string{"id": flags.Arg(0), "ok": "TEMPLATE_DELETED"}, [][]string{{"DELETED", flags.Arg(0)}})
+}
+
+func listTemplates(c *cli, args []string) error {
+	flags := c.flags()
+	options, all := listFlags(flags)
+	if err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	var templates []transloadit.Template
+	if *all {
+		it := client.Templates(c.ctx, options)
+		for it.Next() {
+			templates = append(templates, it.Item())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	} else {
+		list, err := client.ListTemplates(c.ctx, options)
+		if err != nil {
+			return err
+		}
+		templates = list.Templates
+	}
+
+	rows := [][]string{{"ID", "NAME", "STEPS", "SIGNATURE AUTH"}}
+	for _, template := range templates {
+		rows = append(rows, []string{template.ID, template.Name, strconv.Itoa(len(template.Content.Steps)), strconv.FormatBool(template.RequireSignatureAuth)})
+	}
+	return c.print(templates, rows)
+}
+
+func syncTemplates(c *cli, args []string) error {
+	flags := c.flags()
+	var options transloadit.TemplateSyncOptions
+	flags.BoolVar(&options.Delete, "delete", false, "delete templates without a file")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "only show the changes without applying them")
+	if err := c.parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	changes, err := client.SyncTemplates(c.ctx, flags.Arg(0), options)
+	// The changes applied before an error occurred are printed as well.
+	rows := [][]string{{"ACTION", "NAME", "ID"}}
+	for _, change := range changes {
+		rows = append(rows, []string{string(change.Action), change.Name, change.ID})
+		for _, line := range change.Diff {
+			rows = append(rows, []string{"", "  " + line})
+		}
+	}
+	if printErr := c.print(changes, rows); err == nil {
+		err = printErr
+	}
+	return err
+}
+
+// printTemplate prints the template with its content as JSON, since the
+// steps cannot be displayed as table.
+func (c *cli) printTemplate(template transloadit.Template) error {
+	content, err := jsonString(template.Content)
+	if err != nil {
+		return err
+	}
+
+	return c.print(template, [][]string{
+		{"ID", template.ID},
+		{"NAME", template.Name},
+		{"SIGNATURE AUTH", strconv.FormatBool(template.RequireSignatureAuth)},
+		{"CONTENT", content},
+	})
+}
+
+// templateName derives the template name from the file name.
+func templateName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,343 @@
+package transloadit
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultDownloadPattern is used by DownloadResults if
+// DownloadOptions.Pattern is not set.
+const DefaultDownloadPattern = "{step}/{basename}.{ext}"
+
+// defaultDownloadConcurrency is used by DownloadResults if
+// DownloadOptions.Concurrency is not set.
+const defaultDownloadConcurrency = 4
+
+// partialSuffix is appended to the name of files which are being downloaded.
+const partialSuffix = ".part"
+
+// DownloadOptions configures how DownloadResults saves the result files.
+type DownloadOptions struct {
+	// Directory specifies where the files are saved. Files which have only
+	// been downloaded partially are resumed using HTTP range requests and
+	// files which have been downloaded completely are skipped.
+	Directory string
+	// Pattern determines the path of a file relative to Directory. The
+	// placeholders {step}, {id}, {name}, {basename}, {ext} and
+	// {original_basename} are replaced with the corresponding values.
+	// Defaults to DefaultDownloadPattern. If the pattern results in the same
+	// path for multiple files, no file is downloaded and an error is returned.
+	// Including {id} in the pattern makes the paths unique.
+	Pattern string
+	// NewWriter is used to create the destination for each file instead of
+	// saving it in Directory. Downloads cannot be resumed in this case.
+	NewWriter func(step string, file *FileInfo) (io.WriteCloser, error)
+	// Steps limits the download to the results of the provided steps. All
+	// results are downloaded if it is empty.
+	Steps []string
+	// Concurrency is the maximum number of files downloaded in parallel.
+	// Defaults to 4.
+	Concurrency int
+}
+
+// DownloadError is returned by DownloadResults if a result file could not be
+// downloaded.
+type DownloadError struct {
+	Step string
+	File *FileInfo
+	Err  error
+}
+
+// Error returns a formatted message describing the error.
+func (err DownloadError) Error() string {
+	name := err.File.Name
+	for _, fallback := range []string{err.File.ID, err.File.SSLURL, err.File.URL} {
+		if name != "" {
+			break
+		}
+		name = fallback
+	}
+	return fmt.Sprintf("failed to download %s of step %s: %s", name, err.Step, err.Err)
+}
+
+// Unwrap returns the error which caused the download to fail.
+func (err DownloadError) Unwrap() error {
+	return err.Err
+}
+
+// ErrChecksumMismatch is returned, wrapped in a DownloadError, if the MD5
+// hash of a downloaded file does not match FileInfo.Md5Hash.
+var ErrChecksumMismatch = errors.New("transloadit: checksum mismatch")
+
+type downloadJob struct {
+	step string
+	file *FileInfo
+	// path is the destination in DownloadOptions.Directory, if NewWriter is
+	// not set.
+	path string
+}
+
+// DownloadResults downloads the result files of the assembly, which should
+// have finished executing, for example using WaitForAssembly. The MD5 hash of
+// each file is verified if the API reported it. Once a download fails, the
+// remaining ones are stopped and the first error is returned.
+func (client *Client) DownloadResults(ctx context.Context, info *AssemblyInfo, options DownloadOptions) error {
+	if options.Directory == "" && options.NewWriter == nil {
+		return errors.New("failed to download results: neither Directory nor NewWriter is set")
+	}
+
+	if options.Pattern == "" {
+		options.Pattern = DefaultDownloadPattern
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+
+	steps := options.Steps
+	if len(steps) == 0 {
+		for step := range info.Results {
+			steps = append(steps, step)
+		}
+		sort.Strings(steps)
+	}
+
+	// The paths are determined upfront, so that two files are never written to
+	// the same path.
+	var jobs []downloadJob
+	owners := make(map[string]downloadJob)
+	for _, step := range steps {
+		for _, file := range info.Results[step] {
+			job := downloadJob{step: step, file: file}
+			if options.NewWriter == nil {
+				path, err := downloadPath(options.Directory, options.Pattern, step, file)
+				if err != nil {
+					return DownloadError{Step: step, File: file, Err: err}
+				}
+				if owner, ok := owners[path]; ok {
+					return DownloadError{Step: step, File: file, Err: fmt.Errorf("path %s is also used by file %s of step %s, consider adding {id} to the pattern", path, owner.file.ID, owner.step)}
+				}
+				job.path = path
+				owners[path] = job
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan downloadJob)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i := 0; i < concurrency && i < len(jobs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if err := client.downloadResult(ctx, job, options); err != nil {
+					once.Do(func() {
+						firstErr = DownloadError{Step: job.step, File: job.file, Err: err}
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// downloadResult saves a single file either using NewWriter or in Directory.
+func (client *Client) downloadResult(ctx context.Context, job downloadJob, options DownloadOptions) error {
+	if options.NewWriter != nil {
+		writer, err := options.NewWriter(job.step, job.file)
+		if err != nil {
+			return err
+		}
+
+		err = client.download(ctx, job.file, writer, md5.New(), 0)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	name := job.path
+	if downloadComplete(name, job.file) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	partial, err := os.OpenFile(name+partialSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer partial.Close()
+
+	// The hash must include the bytes which have already been downloaded.
+	hash := md5.New()
+	offset, err := io.Copy(hash, partial)
+	if err != nil {
+		return err
+	}
+
+	if err := client.download(ctx, job.file, partial, hash, offset); err != nil {
+		// A corrupted file must not be resumed.
+		if errors.Is(err, ErrChecksumMismatch) {
+			os.Remove(name + partialSuffix)
+		}
+		return err
+	}
+
+	if err := partial.Close(); err != nil {
+		return err
+	}
+	return os.Rename(name+partialSuffix, name)
+}
+
+// download writes the file into writer, starting at offset using a range
+// request, and verifies its checksum. If the server does not support range
+// requests, the writer is truncated if possible and the download restarts
+// from the beginning.
+func (client *Client) download(ctx context.Context, file *FileInfo, writer io.Writer, hash hash.Hash, offset int64) error {
+	fileURL := file.SSLURL
+	if fileURL == "" {
+		fileURL = file.URL
+	}
+
+	req, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The file has already been downloaded completely.
+		return verifyChecksum(file, hash)
+	case res.StatusCode == http.StatusOK:
+		if offset > 0 {
+			partial, ok := writer.(*os.File)
+			if !ok {
+				return errors.New("unable to restart download")
+			}
+			if err := partial.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := partial.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			hash.Reset()
+		}
+	default:
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writer, hash), res.Body); err != nil {
+		return err
+	}
+
+	return verifyChecksum(file, hash)
+}
+
+// verifyChecksum compares the hash of the downloaded data with the one
+// reported by the API, if any.
+func verifyChecksum(file *FileInfo, hash hash.Hash) error {
+	if file.Md5Hash == "" {
+		return nil
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != file.Md5Hash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, file.Md5Hash, sum)
+	}
+	return nil
+}
+
+// downloadComplete reports whether the file at name has been downloaded
+// completely before.
+func downloadComplete(name string, file *FileInfo) bool {
+	stat, err := os.Stat(name)
+	if err != nil || stat.Size() != int64(file.Size) {
+		return false
+	}
+	if file.Md5Hash == "" {
+		return true
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hash.Sum(nil)) == file.Md5Hash
+}
+
+// downloadPath returns the path for the file by expanding the pattern. It
+// fails if the path would be outside of the directory.
+func downloadPath(directory, pattern, step string, file *FileInfo) (string, error) {
+	// Values must not introduce additional path segments.
+	clean := func(value string) string {
+		return strings.NewReplacer("/", "_", "\\", "_").Replace(value)
+	}
+
+	name := strings.NewReplacer(
+		"{step}", clean(step),
+		"{id}", clean(file.ID),
+		"{name}", clean(file.Name),
+		"{basename}", clean(file.Basename),
+		"{ext}", clean(file.Ext),
+		"{original_basename}", clean(file.OriginalBasename),
+	).Replace(pattern)
+
+	relative := filepath.Clean(filepath.FromSlash(name))
+	if relative == "." || relative == ".." || filepath.IsAbs(relative) || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path %q", name)
+	}
+
+	return filepath.Join(directory, relative), nil
+}
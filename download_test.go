@@ -0,0 +1,313 @@
+package transloadit
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newDownloadTestServer serves the provided files, supporting range
+// requests, and records the Range header of each request.
+func newDownloadTestServer(files map[string][]byte) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadResults(t *testing.T) {
+	t.Parallel()
+
+	small := testPayload(100)
+	large := testPayload(10000)
+	server, _ := newDownloadTestServer(map[string][]byte{
+		"/small.jpg": small,
+		"/large.mp4": large,
+	})
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	info := &AssemblyInfo{
+		Results: map[string][]*FileInfo{
+			"thumbs": {{ID: "t1", Basename: "small", Ext: "jpg", Size: len(small), Md5Hash: md5Hex(small), SSLURL: server.URL + "/small.jpg"}},
+			"encode": {{ID: "e1", Basename: "large", Ext: "mp4", Size: len(large), Md5Hash: md5Hex(large), SSLURL: server.URL + "/large.mp4"}},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "transloadit-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = client.DownloadResults(ctx, info, DownloadOptions{
+		Directory:   dir,
+		Concurrency: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string][]byte{
+		"thumbs/small.jpg": small,
+		"encode/large.mp4": large,
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("wrong content of %s", name)
+		}
+	}
+
+	// Only the selected steps are downloaded using the pattern.
+	err = client.DownloadResults(ctx, info, DownloadOptions{
+		Directory: dir,
+		Pattern:   "{id}-{basename}.{ext}",
+		Steps:     []string{"thumbs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "t1-small.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "e1-large.mp4")); !os.IsNotExist(err) {
+		t.Fatal("steps which are not selected should not be downloaded")
+	}
+}
+
+func TestDownloadResults_Resume(t *testing.T) {
+	t.Parallel()
+
+	data := testPayload(10000)
+	server, ranges := newDownloadTestServer(map[string][]byte{"/large.mp4": data})
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	dir, err := ioutil.TempDir("", "transloadit-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "encode", "large.mp4")
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name+partialSuffix, data[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := &AssemblyInfo{
+		Results: map[string][]*FileInfo{
+			"encode": {{Basename: "large", Ext: "mp4", Size: len(data), Md5Hash: md5Hex(data), SSLURL: server.URL + "/large.mp4"}},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.DownloadResults(ctx, info, DownloadOptions{Directory: dir}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	downloaded, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("wrong content of resumed download")
+	}
+	if _, err := os.Stat(name + partialSuffix); !os.IsNotExist(err) {
+		t.Fatal("partial file should be removed")
+	}
+
+	// The second download is skipped since the file is complete.
+	if r := ranges(); len(r) != 1 || r[0] != "bytes=4000-" {
+		t.Fatalf("wrong range requests %q", r)
+	}
+}
+
+func TestDownloadResults_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	data := testPayload(100)
+	server, _ := newDownloadTestServer(map[string][]byte{"/small.jpg": data})
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	file := &FileInfo{Name: "small.jpg", Basename: "small", Ext: "jpg", Md5Hash: md5Hex([]byte("other")), SSLURL: server.URL + "/small.jpg"}
+	info := &AssemblyInfo{
+		Results: map[string][]*FileInfo{"thumbs": {file}},
+	}
+
+	var buf closingBuffer
+	err := client.DownloadResults(ctx, info, DownloadOptions{
+		NewWriter: func(step string, f *FileInfo) (io.WriteCloser, error) {
+			if step != "thumbs" || f != file {
+				t.Errorf("wrong file %s %+v", step, f)
+			}
+			return &buf, nil
+		},
+	})
+
+	var downloadErr DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.Step != "thumbs" || downloadErr.File != file {
+		t.Fatalf("expected DownloadError, got %v", err)
+	}
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || !buf.closed {
+		t.Fatal("writer should receive the data and be closed")
+	}
+}
+
+func TestDownloadResults_DuplicatePath(t *testing.T) {
+	t.Parallel()
+
+	first, second := testPayload(100), testPayload(200)
+	server, requests := newDownloadTestServer(map[string][]byte{
+		"/first.jpg":  first,
+		"/second.jpg": second,
+	})
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	// Both results have the same name, which is common for resized images.
+	info := &AssemblyInfo{
+		Results: map[string][]*FileInfo{
+			"resize": {
+				{ID: "r1", Basename: "photo", Ext: "jpg", Size: len(first), Md5Hash: md5Hex(first), SSLURL: server.URL + "/first.jpg"},
+				{ID: "r2", Basename: "photo", Ext: "jpg", Size: len(second), Md5Hash: md5Hex(second), SSLURL: server.URL + "/second.jpg"},
+			},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "transloadit-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = client.DownloadResults(ctx, info, DownloadOptions{Directory: dir})
+	var downloadErr DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.File.ID != "r2" {
+		t.Fatalf("expected DownloadError for duplicate path, got %v", err)
+	}
+	if len(requests()) != 0 {
+		t.Fatalf("no file should be downloaded, got %d requests", len(requests()))
+	}
+
+	// Including the ID makes the paths unique.
+	err = client.DownloadResults(ctx, info, DownloadOptions{Directory: dir, Pattern: "{step}/{id}_{basename}.{ext}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"r1_photo.jpg": first, "r2_photo.jpg": second} {
+		saved, err := ioutil.ReadFile(filepath.Join(dir, "resize", name))
+		if err != nil || !bytes.Equal(saved, data) {
+			t.Fatalf("wrong content of %s: %v", name, err)
+		}
+	}
+}
+
+func TestDownloadError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file     *FileInfo
+		expected string
+	}{
+		{&FileInfo{ID: "r1", Name: "photo.jpg"}, "failed to download photo.jpg of step resize: failed"},
+		{&FileInfo{ID: "r1", Basename: "photo"}, "failed to download r1 of step resize: failed"},
+		{&FileInfo{SSLURL: "https://example.com/photo.jpg"}, "failed to download https://example.com/photo.jpg of step resize: failed"},
+	}
+	for _, test := range tests {
+		err := DownloadError{Step: "resize", File: test.file, Err: errors.New("failed")}
+		if err.Error() != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, err.Error())
+		}
+	}
+}
+
+func TestDownloadPath(t *testing.T) {
+	t.Parallel()
+
+	file := &FileInfo{ID: "abc", Name: "cat.jpg", Basename: "../cat", Ext: "jpg"}
+
+	name, err := downloadPath("out", DefaultDownloadPattern, "resize", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != filepath.Join("out", "resize", ".._cat.jpg") {
+		t.Fatalf("wrong path %q", name)
+	}
+
+	if _, err := downloadPath("out", "../{name}", "resize", file); err == nil {
+		t.Fatal("paths outside of the directory should be rejected")
+	}
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (buf *closingBuffer) Close() error {
+	buf.closed = true
+	return nil
+}
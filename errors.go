@@ -0,0 +1,69 @@
+package transloadit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrRateLimited matches errors caused by exceeding the rate limit of
+	// the API, after all retries allowed by the RetryPolicy have failed.
+	ErrRateLimited = errors.New("transloadit: rate limit reached")
+	// ErrSignatureInvalid matches errors caused by a request signature which
+	// the API did not accept, usually due to a wrong AuthSecret.
+	ErrSignatureInvalid = errors.New("transloadit: invalid signature")
+	// ErrNotFound matches errors caused by requesting a resource, such as an
+	// assembly or template, which does not exist.
+	ErrNotFound = errors.New("transloadit: not found")
+	// ErrAssemblyFailed matches errors caused by an assembly which could not
+	// be created or which has entered an error state.
+	ErrAssemblyFailed = errors.New("transloadit: assembly failed")
+)
+
+// requestIDHeaders lists the response headers which may contain an
+// identifier for the request, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "X-Amz-Cf-Id"}
+
+// requestID returns the identifier for the request from the response headers.
+func requestID(header http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// AssemblyError is returned if the API accepted a request but the assembly
+// has entered an error state, as indicated by AssemblyInfo.Error. It matches
+// ErrAssemblyFailed using errors.Is.
+type AssemblyError struct {
+	Code    string
+	Message string
+	Reason  string
+
+	AssemblyID     string
+	AssemblySSLURL string
+}
+
+// newAssemblyError returns the error describing the assembly's error state.
+func newAssemblyError(info *AssemblyInfo) AssemblyError {
+	return AssemblyError{
+		Code:           info.Error,
+		Message:        info.Message,
+		Reason:         info.Reason,
+		AssemblyID:     info.AssemblyID,
+		AssemblySSLURL: info.AssemblySSLURL,
+	}
+}
+
+// Error returns a formatted message describing the error.
+func (err AssemblyError) Error() string {
+	return fmt.Sprintf("assembly failed due to %s: %s", err.Code, err.Message)
+}
+
+// Is reports whether target is ErrAssemblyFailed.
+func (err AssemblyError) Is(target error) bool {
+	return target == ErrAssemblyFailed
+}
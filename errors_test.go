@@ -0,0 +1,112 @@
+package transloadit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestError_Fields(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":"INVALID_FILE_META_DATA","message":"broken","reason":"no audio stream","assembly_id":"a1","assembly_ssl_url":"https://example.com/assemblies/a1"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	_, err := client.StartAssembly(ctx, NewAssembly())
+
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected RequestError, got %v", err)
+	}
+	expected := RequestError{
+		Code:           "INVALID_FILE_META_DATA",
+		Message:        "broken",
+		Reason:         "no audio stream",
+		AssemblyID:     "a1",
+		AssemblySSLURL: "https://example.com/assemblies/a1",
+		StatusCode:     http.StatusBadRequest,
+		RequestID:      "req-123",
+	}
+	if reqErr != expected {
+		t.Fatalf("wrong error %+v", reqErr)
+	}
+
+	if !errors.Is(err, ErrAssemblyFailed) {
+		t.Fatal("error should match ErrAssemblyFailed")
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrSignatureInvalid) {
+		t.Fatal("error should not match other sentinels")
+	}
+}
+
+func TestRequestError_Is(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err    error
+		target error
+	}{
+		{RequestError{Code: "RATE_LIMIT_REACHED", StatusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{RequestError{Code: "INVALID_SIGNATURE", StatusCode: http.StatusForbidden}, ErrSignatureInvalid},
+		{RequestError{Code: "TEMPLATE_CREDENTIALS_NOT_READ", StatusCode: http.StatusNotFound}, ErrNotFound},
+		{RequestError{Code: "ASSEMBLY_NOT_FOUND"}, ErrNotFound},
+		{statusError{statusCode: http.StatusNotFound}, ErrNotFound},
+		{statusError{statusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{AssemblyError{Code: "INVALID_FILE_META_DATA"}, ErrAssemblyFailed},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.target) {
+			t.Errorf("%#v should match %v", test.err, test.target)
+		}
+	}
+}
+
+func TestStartAssembly_AssemblyError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"ok":               "REQUEST_ABORTED",
+			"error":            "MAX_SIZE_EXCEEDED",
+			"message":          "too large",
+			"assembly_id":      "a1",
+			"assembly_ssl_url": "https://example.com/assemblies/a1",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	info, err := client.StartAssembly(ctx, NewAssembly())
+	if info == nil || info.Error != "MAX_SIZE_EXCEEDED" {
+		t.Fatalf("assembly info should be returned %+v", info)
+	}
+
+	var assemblyErr AssemblyError
+	if !errors.As(err, &assemblyErr) {
+		t.Fatalf("expected AssemblyError, got %v", err)
+	}
+	if assemblyErr.Code != "MAX_SIZE_EXCEEDED" || assemblyErr.Message != "too large" || assemblyErr.AssemblyID != "a1" {
+		t.Fatalf("wrong error %+v", assemblyErr)
+	}
+	if !errors.Is(err, ErrAssemblyFailed) {
+		t.Fatal("error should match ErrAssemblyFailed")
+	}
+}
@@ -0,0 +1,208 @@
+package transloadit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StepGraph is the directed graph of the `use` relationships between the
+// steps of assembly instructions. It can be exported for visualization using
+// DOT and Mermaid.
+type StepGraph struct {
+	// Steps contains the names of all steps, sorted alphabetically.
+	Steps []string
+	// Uses maps each step to the names of the steps whose results it uses.
+	// These may include :original and steps which are not defined, for
+	// example because they are part of a template.
+	Uses map[string][]string
+	// Robots maps each step to its robot.
+	Robots map[string]string
+}
+
+// NewStepGraph returns the graph of the provided steps in the format of
+// TemplateContent.Steps. It fails if the `use` parameter of a step is
+// malformed.
+func NewStepGraph(steps map[string]interface{}) (*StepGraph, error) {
+	graph := &StepGraph{
+		Steps:  make([]string, 0, len(steps)),
+		Uses:   make(map[string][]string, len(steps)),
+		Robots: make(map[string]string, len(steps)),
+	}
+
+	for name, step := range steps {
+		graph.Steps = append(graph.Steps, name)
+
+		params, ok := step.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("step %s is not an object", name)
+		}
+
+		uses, err := parseUse(params["use"])
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", name, err)
+		}
+		graph.Uses[name] = uses
+		graph.Robots[name], _ = params["robot"].(string)
+	}
+	sort.Strings(graph.Steps)
+
+	return graph, nil
+}
+
+// StepGraph returns the graph of the steps added using AddStep.
+func (assembly *Assembly) StepGraph() (*StepGraph, error) {
+	steps := make(map[string]interface{}, len(assembly.steps))
+	for name, step := range assembly.steps {
+		steps[name] = step
+	}
+	return NewStepGraph(steps)
+}
+
+// StepGraph returns the graph of the content's steps.
+func (content TemplateContent) StepGraph() (*StepGraph, error) {
+	return NewStepGraph(content.Steps)
+}
+
+// TopologicalOrder returns the steps ordered such that each step comes after
+// all steps it uses. Steps which do not depend on each other are ordered
+// alphabetically. Used steps which are not defined, such as :original, are
+// not included. It fails if the steps form a cycle.
+func (graph *StepGraph) TopologicalOrder() ([]string, error) {
+	if cycles := findCycles(graph.Steps, graph.Uses); len(cycles) != 0 {
+		return nil, fmt.Errorf("steps form a cycle: %s", strings.Join(cycles[0], " -> "))
+	}
+
+	pending := make(map[string]int, len(graph.Steps))
+	usedBy := make(map[string][]string, len(graph.Steps))
+	for _, name := range graph.Steps {
+		for _, use := range uniqueStrings(graph.Uses[name]) {
+			if _, ok := graph.Uses[use]; ok {
+				pending[name]++
+				usedBy[use] = append(usedBy[use], name)
+			}
+		}
+	}
+
+	var ready []string
+	for _, name := range graph.Steps {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(graph.Steps))
+	for len(ready) != 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, next := range usedBy[name] {
+			pending[next]--
+			if pending[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// nodes returns the names of all steps and of the used steps which are not
+// defined, sorted alphabetically.
+func (graph *StepGraph) nodes() []string {
+	seen := make(map[string]bool, len(graph.Steps))
+	nodes := make([]string, 0, len(graph.Steps))
+	for _, name := range graph.Steps {
+		seen[name] = true
+		nodes = append(nodes, name)
+	}
+	for _, name := range graph.Steps {
+		for _, use := range graph.Uses[name] {
+			if !seen[use] {
+				seen[use] = true
+				nodes = append(nodes, use)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// DOT returns the graph in the Graphviz DOT language. The edges point from
+// the used step to the step using its results.
+func (graph *StepGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph steps {\n")
+	b.WriteString("  rankdir=LR;\n")
+
+	for _, node := range graph.nodes() {
+		if robot, ok := graph.Robots[node]; ok {
+			fmt.Fprintf(&b, "  %s [label=%s];\n", dotQuote(node), dotQuote(node+"\n"+robot))
+		} else {
+			fmt.Fprintf(&b, "  %s [style=dashed];\n", dotQuote(node))
+		}
+	}
+
+	for _, name := range graph.Steps {
+		for _, use := range uniqueStrings(graph.Uses[name]) {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(use), dotQuote(name))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph as Mermaid flowchart. The edges point from the
+// used step to the step using its results.
+func (graph *StepGraph) Mermaid() string {
+	nodes := graph.nodes()
+	// Step names may contain characters which are not allowed in Mermaid
+	// IDs, so the nodes are numbered instead.
+	ids := make(map[string]string, len(nodes))
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("s%d", i)
+		if robot, ok := graph.Robots[node]; ok {
+			fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n", ids[node], mermaidEscape(node), mermaidEscape(robot))
+		} else {
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", ids[node], mermaidEscape(node))
+		}
+	}
+
+	for _, name := range graph.Steps {
+		for _, use := range uniqueStrings(graph.Uses[name]) {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[use], ids[name])
+		}
+	}
+
+	return b.String()
+}
+
+// dotQuote returns the value as quoted DOT identifier.
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// mermaidEscape escapes characters which would end a quoted Mermaid label.
+func mermaidEscape(value string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(value)
+}
+
+// uniqueStrings returns the values without duplicates, keeping their order.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,90 @@
+package transloadit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestGraph(t *testing.T) *StepGraph {
+	assembly := NewAssembly()
+	assembly.AddStep("resize", map[string]interface{}{"robot": "/image/resize", "use": ":original"})
+	assembly.AddStep("optimize", map[string]interface{}{"robot": "/image/optimize", "use": "resize"})
+	assembly.AddStep("thumbs", map[string]interface{}{"robot": "/video/thumbs", "use": ":original"})
+	assembly.AddStep("store", map[string]interface{}{
+		"robot": "/s3/store",
+		"use":   map[string]interface{}{"steps": []interface{}{"optimize", map[string]interface{}{"name": "thumbs"}, "thumbs"}},
+	})
+
+	graph, err := assembly.StepGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+func TestStepGraph_TopologicalOrder(t *testing.T) {
+	t.Parallel()
+
+	order, err := newTestGraph(t).TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"resize", "optimize", "thumbs", "store"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("wrong order %v", order)
+	}
+
+	content := TemplateContent{Steps: map[string]interface{}{
+		"a": map[string]interface{}{"robot": "/image/resize", "use": "b"},
+		"b": map[string]interface{}{"robot": "/image/resize", "use": "a"},
+	}}
+	graph, err := content.StepGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := graph.TopologicalOrder(); err == nil || err.Error() != "steps form a cycle: a -> b -> a" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestStepGraph_DOT(t *testing.T) {
+	t.Parallel()
+
+	expected := `digraph steps {
+  rankdir=LR;
+  ":original" [style=dashed];
+  "optimize" [label="optimize\n/image/optimize"];
+  "resize" [label="resize\n/image/resize"];
+  "store" [label="store\n/s3/store"];
+  "thumbs" [label="thumbs\n/video/thumbs"];
+  "resize" -> "optimize";
+  ":original" -> "resize";
+  "optimize" -> "store";
+  "thumbs" -> "store";
+  ":original" -> "thumbs";
+}
+`
+	if dot := newTestGraph(t).DOT(); dot != expected {
+		t.Fatalf("wrong DOT output:\n%s", dot)
+	}
+}
+
+func TestStepGraph_Mermaid(t *testing.T) {
+	t.Parallel()
+
+	expected := `flowchart LR
+  s0([":original"])
+  s1["optimize<br/>/image/optimize"]
+  s2["resize<br/>/image/resize"]
+  s3["store<br/>/s3/store"]
+  s4["thumbs<br/>/video/thumbs"]
+  s2 --> s1
+  s0 --> s2
+  s1 --> s3
+  s4 --> s3
+  s0 --> s4
+`
+	if mermaid := newTestGraph(t).Mermaid(); mermaid != expected {
+		t.Fatalf("wrong Mermaid output:\n%s", mermaid)
+	}
+}
@@ -0,0 +1,249 @@
+package transloadit
+
+import "context"
+
+// defaultPageSize is the number of items per page returned by the API if
+// ListOptions.PageSize is not set.
+const defaultPageSize = 50
+
+// pageResult contains the items of a single page and the total number of
+// items, which is -1 if the API does not report it.
+type pageResult struct {
+	items []interface{}
+	count int
+	err   error
+}
+
+// pager fetches the pages of a list lazily and iterates over their items.
+// It is shared by the typed iterators.
+type pager struct {
+	ctx      context.Context
+	fetch    func(ctx context.Context, page int) pageResult
+	pageSize int
+	prefetch bool
+
+	page    int
+	items   []interface{}
+	index   int
+	seen    int
+	done    bool
+	err     error
+	pending chan pageResult
+}
+
+func newPager(ctx context.Context, options *ListOptions, fetch func(ctx context.Context, options ListOptions) pageResult) *pager {
+	var opts ListOptions
+	if options != nil {
+		opts = *options
+	}
+
+	p := &pager{
+		ctx:      ctx,
+		pageSize: opts.PageSize,
+		prefetch: opts.Prefetch,
+		page:     opts.Page,
+		index:    -1,
+	}
+	if p.pageSize <= 0 {
+		p.pageSize = defaultPageSize
+	}
+	if p.page <= 0 {
+		p.page = 1
+	}
+	// The items on the skipped pages count towards the reported total.
+	p.seen = (p.page - 1) * p.pageSize
+
+	p.fetch = func(ctx context.Context, page int) pageResult {
+		pageOptions := opts
+		pageOptions.Page = page
+		return fetch(ctx, pageOptions)
+	}
+
+	return p
+}
+
+// next advances to the next item, fetching the next page if required.
+func (p *pager) next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	if p.index < len(p.items) {
+		return true
+	}
+	if p.done {
+		return false
+	}
+
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+
+	result := p.load()
+	if result.err != nil {
+		p.err = result.err
+		return false
+	}
+
+	p.items = result.items
+	p.index = 0
+	p.seen += len(result.items)
+	p.page++
+
+	// The list has ended if the reported total has been reached or, if the
+	// API does not report it, if the page is not full.
+	if len(result.items) == 0 ||
+		(result.count >= 0 && p.seen >= result.count) ||
+		(result.count < 0 && len(result.items) < p.pageSize) {
+		p.done = true
+	}
+
+	if !p.done && p.prefetch {
+		p.pending = make(chan pageResult, 1)
+		go func(pending chan<- pageResult, page int) {
+			pending <- p.fetch(p.ctx, page)
+		}(p.pending, p.page)
+	}
+
+	return len(p.items) != 0
+}
+
+// load returns the current page, either from the pending prefetch or by
+// fetching it.
+func (p *pager) load() pageResult {
+	if p.pending == nil {
+		return p.fetch(p.ctx, p.page)
+	}
+
+	pending := p.pending
+	p.pending = nil
+	select {
+	case result := <-pending:
+		return result
+	case <-p.ctx.Done():
+		return pageResult{err: p.ctx.Err()}
+	}
+}
+
+// AssemblyIterator iterates over all assemblies matching the ListOptions
+// passed to Client.Assemblies:
+//
+//	it := client.Assemblies(ctx, &transloadit.ListOptions{})
+//	for it.Next() {
+//		assembly := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		panic(err)
+//	}
+type AssemblyIterator struct {
+	pager *pager
+}
+
+// Assemblies returns an iterator over all assemblies matching the provided
+// options. Pages are fetched lazily, starting at ListOptions.Page, while
+// iterating.
+func (client *Client) Assemblies(ctx context.Context, options *ListOptions) *AssemblyIterator {
+	return &AssemblyIterator{newPager(ctx, options, func(ctx context.Context, options ListOptions) pageResult {
+		list, err := client.ListAssemblies(ctx, &options)
+		items := make([]interface{}, len(list.Assemblies))
+		for i, item := range list.Assemblies {
+			items[i] = item
+		}
+		return pageResult{items, list.Count, err}
+	})}
+}
+
+// Next advances the iterator to the next assembly and reports whether there
+// is one. It returns false once all assemblies have been visited or an error
+// occurred.
+func (it *AssemblyIterator) Next() bool {
+	return it.pager.next()
+}
+
+// Item returns the current assembly.
+func (it *AssemblyIterator) Item() *AssemblyListItem {
+	return it.pager.items[it.pager.index].(*AssemblyListItem)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *AssemblyIterator) Err() error {
+	return it.pager.err
+}
+
+// TemplateIterator iterates over all templates matching the ListOptions
+// passed to Client.Templates. It is used like AssemblyIterator.
+type TemplateIterator struct {
+	pager *pager
+}
+
+// Templates returns an iterator over all templates matching the provided
+// options. Pages are fetched lazily, starting at ListOptions.Page, while
+// iterating.
+func (client *Client) Templates(ctx context.Context, options *ListOptions) *TemplateIterator {
+	return &TemplateIterator{newPager(ctx, options, func(ctx context.Context, options ListOptions) pageResult {
+		list, err := client.ListTemplates(ctx, &options)
+		items := make([]interface{}, len(list.Templates))
+		for i, item := range list.Templates {
+			items[i] = item
+		}
+		return pageResult{items, list.Count, err}
+	})}
+}
+
+// Next advances the iterator to the next template and reports whether there
+// is one. It returns false once all templates have been visited or an error
+// occurred.
+func (it *TemplateIterator) Next() bool {
+	return it.pager.next()
+}
+
+// Item returns the current template.
+func (it *TemplateIterator) Item() Template {
+	return it.pager.items[it.pager.index].(Template)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *TemplateIterator) Err() error {
+	return it.pager.err
+}
+
+// TemplateCredentialIterator iterates over all template credentials matching
+// the ListOptions passed to Client.TemplateCredentials. It is used like
+// AssemblyIterator.
+type TemplateCredentialIterator struct {
+	pager *pager
+}
+
+// TemplateCredentials returns an iterator over all template credentials
+// matching the provided options. Since the API does not report the total
+// number of credentials, the iteration ends with the first page which is not
+// full.
+func (client *Client) TemplateCredentials(ctx context.Context, options *ListOptions) *TemplateCredentialIterator {
+	return &TemplateCredentialIterator{newPager(ctx, options, func(ctx context.Context, options ListOptions) pageResult {
+		list, err := client.ListTemplateCredential(ctx, &options)
+		items := make([]interface{}, len(list.TemplateCredential))
+		for i, item := range list.TemplateCredential {
+			items[i] = item
+		}
+		return pageResult{items, -1, err}
+	})}
+}
+
+// Next advances the iterator to the next template credential and reports
+// whether there is one. It returns false once all template credentials have
+// been visited or an error occurred.
+func (it *TemplateCredentialIterator) Next() bool {
+	return it.pager.next()
+}
+
+// Item returns the current template credential.
+func (it *TemplateCredentialIterator) Item() TemplateCredential {
+	return it.pager.items[it.pager.index].(TemplateCredential)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *TemplateCredentialIterator) Err() error {
+	return it.pager.err
+}
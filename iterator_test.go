@@ -0,0 +1,201 @@
+package transloadit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+// requestedPages returns the pages of the list at path which have been
+// requested from the server.
+func requestedPages(server *transloadittest.Server, path string) []int {
+	var pages []int
+	for _, request := range server.Requests() {
+		if request.Method == "GET" && request.Path == path {
+			page, _ := request.Params["page"].(float64)
+			pages = append(pages, int(page))
+		}
+	}
+	return pages
+}
+
+// createAssemblies creates the provided number of assemblies and returns
+// their IDs in the order in which they are listed.
+func createAssemblies(t *testing.T, client transloadit.Client, count int) []string {
+	t.Helper()
+
+	ids := make([]string, count)
+	for i := range ids {
+		info, err := client.StartAssembly(context.Background(), transloadit.NewAssembly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = info.AssemblyID
+	}
+	return ids
+}
+
+func TestAssemblyIterator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+	created := createAssemblies(t, client, 5)
+
+	var ids []string
+	it := client.Assemblies(ctx, &transloadit.ListOptions{PageSize: 2})
+	for it.Next() {
+		ids = append(ids, it.Item().AssemblyID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint(created) {
+		t.Fatalf("wrong assemblies %v", ids)
+	}
+	// The last page is not requested since the count has been reached.
+	if pages := requestedPages(server, "/assemblies"); fmt.Sprint(pages) != "[1 2 3]" {
+		t.Fatalf("wrong pages %v", pages)
+	}
+}
+
+func TestAssemblyIterator_StartPage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+	created := createAssemblies(t, client, 5)
+
+	var ids []string
+	it := client.Assemblies(ctx, &transloadit.ListOptions{PageSize: 2, Page: 2})
+	for it.Next() {
+		ids = append(ids, it.Item().AssemblyID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint(created[2:]) {
+		t.Fatalf("wrong assemblies %v", ids)
+	}
+	// The items on the skipped first page count towards the total, so no
+	// empty page is requested.
+	if pages := requestedPages(server, "/assemblies"); fmt.Sprint(pages) != "[2 3]" {
+		t.Fatalf("wrong pages %v", pages)
+	}
+}
+
+func TestTemplateIterator_Prefetch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+
+	var created []string
+	for i := 0; i < 4; i++ {
+		template := transloadit.NewTemplate()
+		template.Name = fmt.Sprintf("template%d", i)
+		id, err := client.CreateTemplate(ctx, template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, id)
+	}
+
+	var ids []string
+	it := client.Templates(ctx, &transloadit.ListOptions{PageSize: 2, Prefetch: true})
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint(created) {
+		t.Fatalf("wrong templates %v", ids)
+	}
+	if pages := requestedPages(server, "/templates"); fmt.Sprint(pages) != "[1 2]" {
+		t.Fatalf("wrong pages %v", pages)
+	}
+}
+
+func TestTemplateCredentialIterator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+
+	var created []string
+	for i := 0; i < 4; i++ {
+		credential := transloadit.NewTemplateCredential()
+		credential.Name = fmt.Sprintf("credential%d", i)
+		credential.Type = "s3"
+		id, err := client.CreateTemplateCredential(ctx, credential)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, id)
+	}
+
+	var ids []string
+	it := client.TemplateCredentials(ctx, &transloadit.ListOptions{PageSize: 2, Page: 2})
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint(created[2:]) {
+		t.Fatalf("wrong credentials %v", ids)
+	}
+	// Without a count, the iteration ends with the first empty page.
+	if pages := requestedPages(server, "/template_credentials"); fmt.Sprint(pages) != "[2 3]" {
+		t.Fatalf("wrong pages %v", pages)
+	}
+}
+
+func TestAssemblyIterator_Cancel(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+	createAssemblies(t, client, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := client.Assemblies(ctx, &transloadit.ListOptions{PageSize: 2})
+
+	count := 0
+	for it.Next() {
+		count++
+		if count == 2 {
+			cancel()
+		}
+	}
+
+	if it.Err() != context.Canceled {
+		t.Fatalf("unexpected error %v", it.Err())
+	}
+	if pages := requestedPages(server, "/assemblies"); count != 2 || len(pages) != 1 {
+		t.Fatalf("iteration should stop after the first page, got %d items and pages %v", count, pages)
+	}
+}
@@ -0,0 +1,61 @@
+package transloadit
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the transport used for all HTTP requests sent by the
+// client, including file uploads and downloads. It can be used to log
+// requests, record metrics or inject headers, for example:
+//
+//	func userAgent(next http.RoundTripper) http.RoundTripper {
+//		return transloadit.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+//			req = req.Clone(req.Context())
+//			req.Header.Set("User-Agent", "my-service")
+//			return next.RoundTrip(req)
+//		})
+//	}
+//
+// As required by http.RoundTripper, a middleware must not modify the provided
+// request but a copy of it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as
+// http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls fn(req).
+func (fn RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// newHTTPClient returns the HTTP client configured by Config.HTTPClient,
+// Config.Transport and Config.Middleware. The client provided in the config is
+// copied, so that it is not modified.
+func newHTTPClient(config Config) *http.Client {
+	httpClient := &http.Client{}
+	if config.HTTPClient != nil {
+		*httpClient = *config.HTTPClient
+	}
+
+	if config.Transport != nil {
+		httpClient.Transport = config.Transport
+	}
+
+	if len(config.Middleware) == 0 {
+		return httpClient
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	// The first middleware is the outermost one and sees each request first.
+	for i := len(config.Middleware) - 1; i >= 0; i-- {
+		transport = config.Middleware[i](transport)
+	}
+	httpClient.Transport = transport
+
+	return httpClient
+}
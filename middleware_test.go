@@ -0,0 +1,119 @@
+package transloadit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport records the requests and forwards them to the default
+// transport.
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (transport *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport.mu.Lock()
+	transport.requests = append(transport.requests, req)
+	transport.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"ok":     "ASSEMBLY_COMPLETED",
+			"fields": map[string]string{"trace": r.Header.Get("X-Trace")},
+		})
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var order []string
+	middleware := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	transport := &recordingTransport{}
+	httpClient := &http.Client{Timeout: time.Minute}
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+		HTTPClient: httpClient,
+		Transport:  transport,
+		Middleware: []Middleware{middleware("a"), middleware("b")},
+	})
+
+	info, err := client.GetAssembly(ctx, server.URL+"/assemblies/a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Fields["trace"] != "ab" {
+		t.Fatalf("wrong middleware order %v", info.Fields["trace"])
+	}
+	if len(order) != 2 || order[0] != "a" || order[1] != "b" {
+		t.Fatalf("wrong middleware order %v", order)
+	}
+	if len(transport.requests) != 1 || transport.requests[0].Header.Get("X-Trace") != "ab" {
+		t.Fatalf("transport did not receive the request %v", transport.requests)
+	}
+
+	// The provided client is copied, not modified.
+	if httpClient.Transport != nil {
+		t.Fatal("HTTPClient has been modified")
+	}
+	if client.httpClient.Timeout != time.Minute {
+		t.Fatalf("HTTPClient has not been used, timeout is %s", client.httpClient.Timeout)
+	}
+}
+
+func TestMiddleware_HTTPClientTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"ok": "ASSEMBLY_COMPLETED"})
+	}))
+	defer server.Close()
+
+	// Without Config.Transport, the middleware wraps the client's transport.
+	transport := &recordingTransport{}
+	called := false
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+		HTTPClient: &http.Client{Transport: transport},
+		Middleware: []Middleware{
+			func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					called = true
+					return next.RoundTrip(req)
+				})
+			},
+		},
+	})
+
+	if _, err := client.GetAssembly(ctx, server.URL+"/assemblies/a1"); err != nil {
+		t.Fatal(err)
+	}
+	if !called || len(transport.requests) != 1 {
+		t.Fatalf("middleware called: %t, transport requests: %d", called, len(transport.requests))
+	}
+}
@@ -0,0 +1,120 @@
+package transloadit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// maxNotificationSize limits the size of notification bodies which are
+// accepted by the notification handler.
+const maxNotificationSize = 32 * 1024 * 1024
+
+// NotificationHandlerFunc is invoked by the handler returned from
+// NewNotificationHandler for every notification with a valid signature. If
+// it returns an error, Transloadit is informed about the failure using a 500
+// status code and may retry sending the notification.
+type NotificationHandlerFunc func(ctx context.Context, info *AssemblyInfo) error
+
+// NewNotificationHandler returns an http.Handler which receives the
+// notifications sent by Transloadit to an assembly's notify URL. The handler
+// parses the `transloadit` and `signature` form fields, verifies the
+// signature using the provided secret and passes the decoded assembly status
+// to handle. It responds with:
+//
+//	200 OK if handle returned nil,
+//	400 Bad Request if the request is malformed,
+//	403 Forbidden if the signature is invalid,
+//	405 Method Not Allowed for requests other than POST,
+//	500 Internal Server Error if handle returned an error.
+//
+// See https://transloadit.com/docs/topics/assembly-notifications/
+func NewNotificationHandler(secret string, handle NotificationHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxNotificationSize)
+		if err := r.ParseMultipartForm(maxNotificationSize); err != nil && err != http.ErrNotMultipart {
+			http.Error(w, "unable to parse notification: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		payload := r.PostFormValue("transloadit")
+		signature := r.PostFormValue("signature")
+		if payload == "" || signature == "" {
+			http.Error(w, "notification must contain transloadit and signature fields", http.StatusBadRequest)
+			return
+		}
+
+		if !VerifyNotificationSignature(secret, payload, signature) {
+			http.Error(w, "invalid notification signature", http.StatusForbidden)
+			return
+		}
+
+		var info AssemblyInfo
+		if err := json.Unmarshal([]byte(payload), &info); err != nil {
+			http.Error(w, "unable to decode notification: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := handle(r.Context(), &info); err != nil {
+			http.Error(w, "unable to handle notification", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// NotificationHandler returns an http.Handler for receiving notifications
+// which verifies their signatures using the client's Config.AuthSecret. See
+// NewNotificationHandler for details.
+func (client *Client) NotificationHandler(handle NotificationHandlerFunc) http.Handler {
+	return NewNotificationHandler(client.config.AuthSecret, handle)
+}
+
+// VerifyNotificationSignature reports whether the signature sent alongside a
+// notification matches the payload from the `transloadit` form field.
+// Signatures may be prefixed with the used algorithm (`sha1:`, `sha256:`,
+// `sha384:` or `sha512:`). Signatures without a prefix are SHA-1 based.
+func VerifyNotificationSignature(secret, payload, signature string) bool {
+	algorithm := "sha1"
+	if i := strings.IndexByte(signature, ':'); i != -1 {
+		algorithm = signature[:i]
+		signature = signature[i+1:]
+	}
+
+	var newHash func() hash.Hash
+	switch algorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
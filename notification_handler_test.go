@@ -0,0 +1,142 @@
+package transloadit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const notificationPayload = `{"ok":"ASSEMBLY_COMPLETED","assembly_id":"a1","results":{"resize":[{"id":"f1","ssl_url":"https://example.com/f1.jpg"}]}}`
+
+func signNotification(payload string) string {
+	mac := hmac.New(sha512.New384, []byte("secret"))
+	mac.Write([]byte(payload))
+	return "sha384:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func postNotification(handler http.Handler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/notify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNotificationHandler_Success(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+	})
+
+	var received *AssemblyInfo
+	handler := client.NotificationHandler(func(ctx context.Context, info *AssemblyInfo) error {
+		received = info
+		return nil
+	})
+
+	rec := postNotification(handler, url.Values{
+		"transloadit": {notificationPayload},
+		"signature":   {signNotification(notificationPayload)},
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("wrong status code %d: %s", rec.Code, rec.Body.String())
+	}
+	if received == nil || received.AssemblyID != "a1" {
+		t.Fatal("handler received wrong assembly info")
+	}
+	if received.Results["resize"][0].ID != "f1" {
+		t.Fatal("handler received wrong results")
+	}
+}
+
+func TestNotificationHandler_Multipart(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("transloadit", notificationPayload)
+	writer.WriteField("signature", signNotification(notificationPayload))
+	writer.Close()
+
+	handled := false
+	handler := NewNotificationHandler("secret", func(ctx context.Context, info *AssemblyInfo) error {
+		handled = true
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/notify", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !handled {
+		t.Fatalf("wrong status code %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestNotificationHandler_Errors(t *testing.T) {
+	t.Parallel()
+
+	handler := NewNotificationHandler("secret", func(ctx context.Context, info *AssemblyInfo) error {
+		if info.AssemblyID == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	failingPayload := `{"assembly_id":"fail"}`
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"missing signature", url.Values{"transloadit": {notificationPayload}}, http.StatusBadRequest},
+		{"invalid signature", url.Values{"transloadit": {notificationPayload}, "signature": {signNotification("other")}}, http.StatusForbidden},
+		{"unknown algorithm", url.Values{"transloadit": {notificationPayload}, "signature": {"md5:abc"}}, http.StatusForbidden},
+		{"invalid json", url.Values{"transloadit": {"{"}, "signature": {signNotification("{")}}, http.StatusBadRequest},
+		{"handler error", url.Values{"transloadit": {failingPayload}, "signature": {signNotification(failingPayload)}}, http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if rec := postNotification(handler, test.form); rec.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/notify", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d for GET, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func TestVerifyNotificationSignature_Sha1(t *testing.T) {
+	t.Parallel()
+
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(notificationPayload))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	if !VerifyNotificationSignature("secret", notificationPayload, signature) {
+		t.Fatal("unprefixed sha1 signature should be valid")
+	}
+	if !VerifyNotificationSignature("secret", notificationPayload, "sha1:"+signature) {
+		t.Fatal("prefixed sha1 signature should be valid")
+	}
+	if VerifyNotificationSignature("other", notificationPayload, signature) {
+		t.Fatal("signature should be invalid for a different secret")
+	}
+}
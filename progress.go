@@ -0,0 +1,89 @@
+package transloadit
+
+import "io"
+
+// UploadProgress describes how many bytes of the files added to an assembly
+// have been sent to the API.
+type UploadProgress struct {
+	// Field and Name identify the file which is currently being sent.
+	Field string
+	Name  string
+	// BytesSent is the number of bytes of the current file sent so far.
+	BytesSent int64
+	// BytesExpected is the size of the current file or -1 if it is unknown.
+	BytesExpected int64
+	// TotalBytesSent is the number of bytes of all files sent so far.
+	TotalBytesSent int64
+	// TotalBytesExpected is the size of all files or -1 if the size of at
+	// least one file is unknown.
+	TotalBytesExpected int64
+}
+
+// ProgressFunc is invoked by StartAssembly each time data of a file has been
+// sent. It is called from the goroutine writing the request body, so it
+// should return quickly to avoid slowing down the upload.
+type ProgressFunc func(progress UploadProgress)
+
+// uploadProgress keeps track of the bytes sent for all uploads of an
+// assembly. The sizes must be determined before the readers are consumed.
+type uploadProgress struct {
+	onProgress    ProgressFunc
+	sizes         []int64
+	totalSent     int64
+	totalExpected int64
+}
+
+func newUploadProgress(uploads []*upload, onProgress ProgressFunc) *uploadProgress {
+	progress := &uploadProgress{
+		onProgress: onProgress,
+		sizes:      make([]int64, len(uploads)),
+	}
+
+	for i, upload := range uploads {
+		size, ok := readerSize(upload.Reader)
+		if !ok {
+			size = -1
+		}
+		progress.sizes[i] = size
+
+		if size == -1 || progress.totalExpected == -1 {
+			progress.totalExpected = -1
+		} else {
+			progress.totalExpected += size
+		}
+	}
+
+	return progress
+}
+
+// reader wraps the reader of the upload at index i, so that every read is
+// reported to the callback.
+func (progress *uploadProgress) reader(i int, upload *upload) io.Reader {
+	return &progressReader{
+		reader:   upload.Reader,
+		progress: progress,
+		current: UploadProgress{
+			Field:         upload.Field,
+			Name:          upload.Name,
+			BytesExpected: progress.sizes[i],
+		},
+	}
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *uploadProgress
+	current  UploadProgress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.current.BytesSent += int64(n)
+		r.progress.totalSent += int64(n)
+		r.current.TotalBytesSent = r.progress.totalSent
+		r.current.TotalBytesExpected = r.progress.totalExpected
+		r.progress.onProgress(r.current)
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+package transloadit
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestStartAssembly_Progress(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		writeJSON(w, map[string]string{"ok": "ASSEMBLY_UPLOADING"})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	stat, err := os.Stat("./fixtures/lol_cat.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var updates []UploadProgress
+	assembly := NewAssembly()
+	assembly.OnProgress = func(progress UploadProgress) {
+		updates = append(updates, progress)
+	}
+	assembly.AddReader("image", "sized.bin", sizedReader{bytes.NewReader(testPayload(1000))})
+	if err := assembly.AddFile("photo", "./fixtures/lol_cat.jpg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.StartAssembly(ctx, assembly); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 1000 + stat.Size()
+	last := updates[len(updates)-1]
+	if last.Field != "photo" || last.BytesSent != stat.Size() || last.BytesExpected != stat.Size() {
+		t.Fatalf("wrong final progress %+v", last)
+	}
+	if last.TotalBytesSent != total || last.TotalBytesExpected != total {
+		t.Fatalf("wrong total progress %+v", last)
+	}
+
+	var sent int64
+	for _, update := range updates {
+		if update.TotalBytesSent < sent {
+			t.Fatalf("progress went backwards %+v", update)
+		}
+		sent = update.TotalBytesSent
+	}
+	if updates[0].Field != "image" || updates[0].BytesExpected != 1000 {
+		t.Fatalf("wrong first progress %+v", updates[0])
+	}
+}
+
+func TestStartAssembly_ProgressUnknownSize(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		writeJSON(w, map[string]string{"ok": "ASSEMBLY_UPLOADING"})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	var last UploadProgress
+	assembly := NewAssembly()
+	assembly.OnProgress = func(progress UploadProgress) {
+		last = progress
+	}
+	assembly.AddReader("image", "sized.bin", sizedReader{bytes.NewReader(testPayload(100))})
+	assembly.AddReader("video", "unsized.bin", unsizedReader{bytes.NewReader(testPayload(200))})
+
+	if _, err := client.StartAssembly(ctx, assembly); err != nil {
+		t.Fatal(err)
+	}
+
+	if last.Name != "unsized.bin" || last.BytesSent != 200 || last.BytesExpected != -1 {
+		t.Fatalf("wrong progress %+v", last)
+	}
+	if last.TotalBytesSent != 300 || last.TotalBytesExpected != -1 {
+		t.Fatalf("wrong total progress %+v", last)
+	}
+}
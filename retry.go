@@ -0,0 +1,195 @@
+package transloadit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy defines how failed API requests are retried. A request is
+// retried if the connection fails, if the API responds with a server error
+// (5xx) or if the returned RequestError.Code is contained in RetryableCodes.
+// Each retry is signed again, so that its signature neither expires nor gets
+// rejected as reused.
+//
+// Please be aware that requests which create resources, such as
+// Client.CreateTemplate, may be executed twice if the API processed the first
+// attempt but the response was lost. File uploads using Client.StartAssembly
+// are never retried since their body can only be read once. Use the resumable
+// tus mode (Assembly.UseTus) for those instead.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per request, including the
+	// first one. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It is doubled for each
+	// following retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts. It does not apply to
+	// delays requested by the API using the Retry-After header or the
+	// info.retryIn property.
+	MaxDelay time.Duration
+	// Jitter is the fraction (between 0 and 1) by which each delay is randomly
+	// shortened to avoid many clients retrying at the same time.
+	Jitter float64
+	// RetryableCodes contains the error codes returned by the API (see
+	// RequestError.Code) for which a request is retried.
+	RetryableCodes []string
+}
+
+// DefaultRetryPolicy is the recommended policy for retrying requests.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	BaseDelay:      500 * time.Millisecond,
+	MaxDelay:       30 * time.Second,
+	Jitter:         0.2,
+	RetryableCodes: []string{"RATE_LIMIT_REACHED"},
+}
+
+// statusError is returned by Client.doRequest if the API responded with an
+// unsuccessful status code but the body could not be decoded as RequestError.
+type statusError struct {
+	statusCode int
+	retryAfter time.Duration
+	err        error
+}
+
+func (err statusError) Error() string {
+	return err.err.Error()
+}
+
+func (err statusError) Unwrap() error {
+	return err.err
+}
+
+// Is reports whether the status code matches one of the sentinel errors
+// ErrRateLimited or ErrNotFound.
+func (err statusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return err.statusCode == http.StatusTooManyRequests
+	case ErrNotFound:
+		return err.statusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// doRequestWithRetry executes the requests returned by newRequest until one
+// succeeds or the client's RetryPolicy does not allow another attempt.
+// newRequest is invoked for every attempt, allowing it to sign the request
+// again.
+func (client *Client) doRequestWithRetry(ctx context.Context, newRequest func() (*http.Request, error), result interface{}) error {
+	policy := client.config.RetryPolicy
+
+	resigned := false
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+
+		err = client.doRequest(req, result)
+
+		// The clock has been corrected by doRequest, so signing the request
+		// again should succeed. This does not count as attempt.
+		if isSignatureExpired(err) && !resigned && ctx.Err() == nil {
+			resigned = true
+			attempt--
+			continue
+		}
+
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		retryable, retryAfter := policy.retryable(err)
+		if !retryable {
+			return err
+		}
+
+		delay := retryAfter
+		if delay <= 0 {
+			delay = policy.backoff(attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable reports whether the request which resulted in err should be
+// retried and the delay requested by the API, if any.
+func (policy RetryPolicy) retryable(err error) (bool, time.Duration) {
+	var reqErr RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.StatusCode >= 500 {
+			return true, reqErr.retryAfter
+		}
+		for _, code := range policy.RetryableCodes {
+			if reqErr.Code == code {
+				return true, reqErr.retryAfter
+			}
+		}
+		return false, 0
+	}
+
+	var statusErr statusError
+	if errors.As(err, &statusErr) {
+		retryable := statusErr.statusCode >= 500 || statusErr.statusCode == http.StatusTooManyRequests
+		return retryable, statusErr.retryAfter
+	}
+
+	// Errors from the HTTP client, for example if the connection was reset.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr), 0
+}
+
+// backoff returns the delay before the attempt following the provided one.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(policy.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if policy.MaxDelay > 0 && delay > float64(policy.MaxDelay) {
+		delay = float64(policy.MaxDelay)
+	}
+
+	if policy.Jitter > 0 {
+		delay -= delay * math.Min(policy.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// parseRetryAfter extracts the delay requested by the API, either from the
+// Retry-After header (in seconds or as HTTP date) or from the info.retryIn
+// property (in seconds) of the response body.
+func parseRetryAfter(header string, body []byte) time.Duration {
+	if header != "" {
+		if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(header); err == nil {
+			if delay := time.Until(date); delay > 0 {
+				return delay
+			}
+			return 0
+		}
+	}
+
+	var hint struct {
+		Info struct {
+			RetryIn float64 `json:"retryIn"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(body, &hint); err == nil && hint.Info.RetryIn > 0 {
+		return time.Duration(hint.Info.RetryIn * float64(time.Second))
+	}
+
+	return 0
+}
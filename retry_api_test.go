@@ -0,0 +1,158 @@
+package transloadit_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+// newRetryTestClient returns a client for the server which retries requests
+// without noticeable delays.
+func newRetryTestClient(server *transloadittest.Server) transloadit.Client {
+	config := server.Config()
+	config.RetryPolicy = transloadit.RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		RetryableCodes: []string{"RATE_LIMIT_REACHED"},
+	}
+	return transloadit.NewClient(config)
+}
+
+// attempts returns the params of the requests to the path.
+func attempts(server *transloadittest.Server, method, path string) []map[string]interface{} {
+	var params []map[string]interface{}
+	for _, request := range server.Requests() {
+		if request.Method == method && request.Path == path {
+			params = append(params, request.Params)
+		}
+	}
+	return params
+}
+
+func TestRetry_ServerError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := newRetryTestClient(server)
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.InjectFault(transloadittest.Fault{
+		Path:       "/assemblies/*",
+		StatusCode: http.StatusBadGateway,
+		Body:       "<html>Bad Gateway</html>",
+		Times:      1,
+	})
+	server.InjectFault(transloadittest.Fault{
+		Path:    "/assemblies/*",
+		Code:    "INTERNAL_ERROR",
+		Message: "oops",
+		Times:   1,
+	})
+
+	status, err := client.GetAssembly(ctx, info.AssemblySSLURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.AssemblyID != info.AssemblyID {
+		t.Fatalf("wrong assembly id %q", status.AssemblyID)
+	}
+
+	params := attempts(server, "GET", "/assemblies/"+info.AssemblyID)
+	if len(params) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(params))
+	}
+
+	// Every attempt must be signed again with a new nonce.
+	nonces := make(map[float64]bool)
+	for _, p := range params {
+		nonces[p["nonce"].(float64)] = true
+	}
+	if len(nonces) != 3 {
+		t.Fatal("attempts should use different nonces")
+	}
+}
+
+func TestRetry_RetryIn(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.InjectFault(transloadittest.Fault{
+		Path:       "/templates",
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Body:       `{"error":"RATE_LIMIT_REACHED","message":"slow down","info":{"retryIn":0.05}}`,
+		Times:      1,
+	})
+
+	client := newRetryTestClient(server)
+	start := time.Now()
+	if _, err := client.ListTemplates(ctx, &transloadit.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("retryIn hint was not honoured, retried after %s", elapsed)
+	}
+	if n := len(attempts(server, "GET", "/templates")); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := newRetryTestClient(server)
+	_, err := client.GetTemplate(ctx, "foo")
+
+	reqErr, ok := err.(transloadit.RequestError)
+	if !ok || reqErr.Code != "TEMPLATE_NOT_FOUND" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if n := len(attempts(server, "GET", "/templates/foo")); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.InjectFault(transloadittest.Fault{
+		Method:     "DELETE",
+		Path:       "/templates/*",
+		StatusCode: http.StatusServiceUnavailable,
+		Code:       "SERVER_ERROR",
+		Message:    "unavailable",
+	})
+
+	client := newRetryTestClient(server)
+	err := client.DeleteTemplate(ctx, "foo")
+
+	reqErr, ok := err.(transloadit.RequestError)
+	if !ok || reqErr.Code != "SERVER_ERROR" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if n := len(attempts(server, "DELETE", "/templates/foo")); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+}
@@ -0,0 +1,51 @@
+package transloadit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	if delay := parseRetryAfter("3", nil); delay != 3*time.Second {
+		t.Fatalf("wrong delay %s", delay)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if delay := parseRetryAfter(date, nil); delay <= 50*time.Second || delay > time.Minute {
+		t.Fatalf("wrong delay %s", delay)
+	}
+
+	if delay := parseRetryAfter("", []byte(`{"info":{"retryIn":1.5}}`)); delay != 1500*time.Millisecond {
+		t.Fatalf("wrong delay %s", delay)
+	}
+
+	if delay := parseRetryAfter("", []byte(`{"error":"FOO"}`)); delay != 0 {
+		t.Fatalf("wrong delay %s", delay)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, want := range expected {
+		if delay := policy.backoff(i + 1); delay != want*time.Millisecond {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, want*time.Millisecond, delay)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := policy.backoff(2); delay < 100*time.Millisecond || delay > 200*time.Millisecond {
+			t.Fatalf("delay %s out of jitter range", delay)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package robots
+
+// AudioEncode converts audio files into all kinds of formats.
+// See https://transloadit.com/docs/transcoding/audio-encoding/audio-encode/
+type AudioEncode struct {
+	Base
+
+	Preset      string                 `json:"preset,omitempty"`
+	Bitrate     int                    `json:"bitrate,omitempty"`
+	SampleRate  int                    `json:"sample_rate,omitempty"`
+	FFmpeg      map[string]interface{} `json:"ffmpeg,omitempty"`
+	FFmpegStack string                 `json:"ffmpeg_stack,omitempty"`
+}
+
+// Robot implements Step.
+func (step AudioEncode) Robot() string { return "/audio/encode" }
+
+// Map implements Step.
+func (step AudioEncode) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
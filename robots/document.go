@@ -0,0 +1,40 @@
+package robots
+
+// DocumentConvert converts documents into different formats, for example
+// Word documents into PDFs.
+// See https://transloadit.com/docs/transcoding/document-processing/document-convert/
+type DocumentConvert struct {
+	Base
+
+	Format             string `json:"format,omitempty"`
+	MarkdownFormat     string `json:"markdown_format,omitempty"`
+	MarkdownTheme      string `json:"markdown_theme,omitempty"`
+	PDFMargin          string `json:"pdf_margin,omitempty"`
+	PDFPrintBackground *bool  `json:"pdf_print_background,omitempty"`
+	PDFFormat          string `json:"pdf_format,omitempty"`
+}
+
+// Robot implements Step.
+func (step DocumentConvert) Robot() string { return "/document/convert" }
+
+// Map implements Step.
+func (step DocumentConvert) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
+
+// HTMLConvert takes screenshots of web pages or HTML files.
+// See https://transloadit.com/docs/transcoding/document-processing/html-convert/
+type HTMLConvert struct {
+	Base
+
+	URL      string `json:"url,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Fullpage *bool  `json:"fullpage,omitempty"`
+	Delay    int    `json:"delay,omitempty"`
+}
+
+// Robot implements Step.
+func (step HTMLConvert) Robot() string { return "/html/convert" }
+
+// Map implements Step.
+func (step HTMLConvert) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
@@ -0,0 +1,39 @@
+package robots
+
+// Condition is a single rule used by FileFilter, consisting of a value, an
+// operator and a second value, for example:
+//
+//	robots.Condition{"${file.mime}", "regex", "image"}
+type Condition [3]interface{}
+
+// FileFilter directs files to different encoding steps based on their
+// metadata.
+// See https://transloadit.com/docs/transcoding/file-filtering/file-filter/
+type FileFilter struct {
+	Base
+
+	Accepts        []Condition `json:"accepts,omitempty"`
+	Declines       []Condition `json:"declines,omitempty"`
+	ConditionType  string      `json:"condition_type,omitempty"`
+	ErrorOnDecline bool        `json:"error_on_decline,omitempty"`
+	ErrorMsg       string      `json:"error_msg,omitempty"`
+}
+
+// Robot implements Step.
+func (step FileFilter) Robot() string { return "/file/filter" }
+
+// Map implements Step.
+func (step FileFilter) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
+
+// UploadHandle receives the files uploaded alongside the assembly. It is
+// usually added as the `:original` step.
+// See https://transloadit.com/docs/transcoding/handling-uploads/upload-handle/
+type UploadHandle struct {
+	Base
+}
+
+// Robot implements Step.
+func (step UploadHandle) Robot() string { return "/upload/handle" }
+
+// Map implements Step.
+func (step UploadHandle) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
@@ -0,0 +1,47 @@
+package robots
+
+// ImageResize resizes, crops, changes colorization, rotates or applies text
+// and watermarks to images.
+// See https://transloadit.com/docs/transcoding/image-manipulation/image-resize/
+type ImageResize struct {
+	Base
+
+	Format            string `json:"format,omitempty"`
+	Width             int    `json:"width,omitempty"`
+	Height            int    `json:"height,omitempty"`
+	ResizeStrategy    string `json:"resize_strategy,omitempty"`
+	Zoom              *bool  `json:"zoom,omitempty"`
+	Gravity           string `json:"gravity,omitempty"`
+	Strip             bool   `json:"strip,omitempty"`
+	Background        string `json:"background,omitempty"`
+	Quality           int    `json:"quality,omitempty"`
+	Progressive       bool   `json:"progressive,omitempty"`
+	TrimWhitespace    bool   `json:"trim_whitespace,omitempty"`
+	WatermarkURL      string `json:"watermark_url,omitempty"`
+	WatermarkPosition string `json:"watermark_position,omitempty"`
+	WatermarkSize     string `json:"watermark_size,omitempty"`
+	ImageMagickStack  string `json:"imagemagick_stack,omitempty"`
+}
+
+// Robot implements Step.
+func (step ImageResize) Robot() string { return "/image/resize" }
+
+// Map implements Step.
+func (step ImageResize) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
+
+// ImageOptimize reduces the size of images while preserving their quality.
+// See https://transloadit.com/docs/transcoding/image-manipulation/image-optimize/
+type ImageOptimize struct {
+	Base
+
+	Priority          string `json:"priority,omitempty"`
+	Progressive       bool   `json:"progressive,omitempty"`
+	PreserveMetaData  *bool  `json:"preserve_meta_data,omitempty"`
+	FixBreakingImages *bool  `json:"fix_breaking_images,omitempty"`
+}
+
+// Robot implements Step.
+func (step ImageOptimize) Robot() string { return "/image/optimize" }
+
+// Map implements Step.
+func (step ImageOptimize) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
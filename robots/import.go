@@ -0,0 +1,20 @@
+package robots
+
+// HTTPImport imports files from web servers using HTTP or HTTPS.
+// See https://transloadit.com/docs/transcoding/file-importing/http-import/
+type HTTPImport struct {
+	Base
+
+	URL            string   `json:"url,omitempty"`
+	Headers        []string `json:"headers,omitempty"`
+	ImportOnErrors []string `json:"import_on_errors,omitempty"`
+	FailFast       bool     `json:"fail_fast,omitempty"`
+	Credentials    string   `json:"credentials,omitempty"`
+	ForceName      string   `json:"force_name,omitempty"`
+}
+
+// Robot implements Step.
+func (step HTTPImport) Robot() string { return "/http/import" }
+
+// Map implements Step.
+func (step HTTPImport) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
@@ -0,0 +1,109 @@
+// Package robots provides typed assembly steps for commonly used Transloadit
+// robots. Each step can be converted into the map format accepted by
+// Assembly.AddStep, AssemblyReplay.AddStep and Template.AddStep from the
+// transloadit package:
+//
+//	assembly.AddStep("resize", robots.ImageResize{
+//		Base:           robots.Base{Use: robots.Use{":original"}},
+//		Width:          75,
+//		Height:         75,
+//		ResizeStrategy: "pad",
+//	}.Map())
+//
+// Parameters which are not covered by the structs can be supplied using
+// Base.Extra. A list of all robots and their parameters can be found at
+// https://transloadit.com/docs/transcoding/
+package robots
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Step is implemented by all robot structs in this package.
+type Step interface {
+	// Robot returns the name of the robot executing the step, for example
+	// "/image/resize".
+	Robot() string
+	// Map returns the step's parameters including the `robot` key in the
+	// format accepted by the AddStep methods of the transloadit package.
+	Map() map[string]interface{}
+}
+
+// Base contains the parameters which are shared by all robots. It is
+// embedded in every robot struct.
+type Base struct {
+	// Use specifies the names of the steps whose results are passed to this
+	// step, for example ":original" for the uploaded files.
+	Use Use `json:"use,omitempty"`
+	// Result specifies whether the step's results are included in the
+	// assembly status and permanently stored.
+	Result bool `json:"result,omitempty"`
+	// Queue allows assigning the step to the "batch" queue which processes
+	// non-urgent jobs with a lower priority.
+	Queue string `json:"queue,omitempty"`
+	// Force specifies whether the step is executed even if its results are
+	// not used by any other step.
+	Force bool `json:"force,omitempty"`
+	// Extra contains additional parameters which are added to the step as
+	// is. They take precedence over the struct's fields.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// Use is a list of step names. A single name is encoded as a JSON string and
+// multiple names are encoded as a JSON array.
+type Use []string
+
+// MarshalJSON implements json.Marshaler.
+func (use Use) MarshalJSON() ([]byte, error) {
+	if len(use) == 1 {
+		return json.Marshal(use[0])
+	}
+
+	return json.Marshal([]string(use))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (use *Use) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*use = Use{name}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return fmt.Errorf("robots: use must be a string or a list of strings: %s", err)
+	}
+
+	*use = Use(names)
+	return nil
+}
+
+// toMap converts the JSON representation of the step into a map, adds the
+// robot's name and merges the step's extra parameters.
+func toMap(robot string, base Base, step interface{}) map[string]interface{} {
+	b, err := json.Marshal(step)
+	if err != nil {
+		// The robot structs only contain types which can always be encoded.
+		panic(fmt.Sprintf("robots: unable to encode %s step: %s", robot, err))
+	}
+
+	params := make(map[string]interface{})
+	if err := json.Unmarshal(b, &params); err != nil {
+		panic(fmt.Sprintf("robots: unable to decode %s step: %s", robot, err))
+	}
+
+	params["robot"] = robot
+	for key, value := range base.Extra {
+		params[key] = value
+	}
+
+	return params
+}
+
+// Bool returns a pointer to the provided value. It can be used for optional
+// parameters whose default value is true, such as ImageResize.Zoom.
+func Bool(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,144 @@
+package robots_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/robots"
+)
+
+func TestImageResize_Map(t *testing.T) {
+	t.Parallel()
+
+	step := robots.ImageResize{
+		Base: robots.Base{
+			Use:    robots.Use{":original"},
+			Result: true,
+			Extra: map[string]interface{}{
+				"imagemagick_stack": "v3.0.0",
+				"sepia":             80,
+			},
+		},
+		Width:          75,
+		Height:         75,
+		ResizeStrategy: "pad",
+		Zoom:           robots.Bool(false),
+		Background:     "#000000",
+		// Overwritten by Extra
+		ImageMagickStack: "v2.0.7",
+	}
+
+	expected := map[string]interface{}{
+		"robot":             "/image/resize",
+		"use":               ":original",
+		"result":            true,
+		"width":             float64(75),
+		"height":            float64(75),
+		"resize_strategy":   "pad",
+		"zoom":              false,
+		"background":        "#000000",
+		"imagemagick_stack": "v3.0.0",
+		"sepia":             80,
+	}
+
+	if actual := step.Map(); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("wrong map:\nexpected %#v\nactual   %#v", expected, actual)
+	}
+}
+
+func TestUse_JSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(robots.Use{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["a","b"]` {
+		t.Fatalf("wrong encoding %s", b)
+	}
+
+	var use robots.Use
+	if err := json.Unmarshal([]byte(`":original"`), &use); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(use, robots.Use{":original"}) {
+		t.Fatalf("wrong decoding %#v", use)
+	}
+
+	if err := json.Unmarshal([]byte(`["a","b"]`), &use); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(use, robots.Use{"a", "b"}) {
+		t.Fatalf("wrong decoding %#v", use)
+	}
+
+	if err := json.Unmarshal([]byte(`42`), &use); err == nil {
+		t.Fatal("expected an error for invalid use")
+	}
+}
+
+func TestSteps_Template(t *testing.T) {
+	t.Parallel()
+
+	template := transloadit.NewTemplate()
+	template.AddStep(":original", robots.UploadHandle{}.Map())
+	template.AddStep("filter", robots.FileFilter{
+		Base:          robots.Base{Use: robots.Use{":original"}},
+		Accepts:       []robots.Condition{{"${file.mime}", "regex", "video"}},
+		ConditionType: "and",
+	}.Map())
+	template.AddStep("encode", robots.VideoEncode{
+		Base:   robots.Base{Use: robots.Use{"filter"}},
+		Preset: "ipad-high",
+		FFmpeg: map[string]interface{}{"b:v": "1M"},
+	}.Map())
+	template.AddStep("store", robots.S3Store{
+		Base:        robots.Base{Use: robots.Use{"filter", "encode"}},
+		Credentials: "my_s3",
+		Path:        "${assembly.id}/${file.name}",
+	}.Map())
+
+	actual, err := json.Marshal(template.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"steps":{` +
+		`":original":{"robot":"/upload/handle"},` +
+		`"encode":{"ffmpeg":{"b:v":"1M"},"preset":"ipad-high","robot":"/video/encode","use":"filter"},` +
+		`"filter":{"accepts":[["${file.mime}","regex","video"]],"condition_type":"and","robot":"/file/filter","use":":original"},` +
+		`"store":{"credentials":"my_s3","path":"${assembly.id}/${file.name}","robot":"/s3/store","use":["filter","encode"]}` +
+		`}}`
+	if string(actual) != expected {
+		t.Fatalf("wrong JSON:\nexpected %s\nactual   %s", expected, actual)
+	}
+}
+
+func TestSteps_Robots(t *testing.T) {
+	t.Parallel()
+
+	steps := map[string]robots.Step{
+		"/image/resize":     robots.ImageResize{},
+		"/image/optimize":   robots.ImageOptimize{},
+		"/video/encode":     robots.VideoEncode{},
+		"/video/thumbs":     robots.VideoThumbs{},
+		"/audio/encode":     robots.AudioEncode{},
+		"/file/filter":      robots.FileFilter{},
+		"/upload/handle":    robots.UploadHandle{},
+		"/s3/store":         robots.S3Store{},
+		"/http/import":      robots.HTTPImport{},
+		"/document/convert": robots.DocumentConvert{},
+		"/html/convert":     robots.HTMLConvert{},
+	}
+
+	for robot, step := range steps {
+		if step.Robot() != robot {
+			t.Errorf("expected robot %s, got %s", robot, step.Robot())
+		}
+		if m := step.Map(); len(m) != 1 || m["robot"] != robot {
+			t.Errorf("empty %s step should only contain the robot, got %v", robot, m)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package robots
+
+// S3Store exports files to Amazon S3 or S3-compatible storage.
+// See https://transloadit.com/docs/transcoding/file-exporting/s3-store/
+type S3Store struct {
+	Base
+
+	// Credentials is the name of the template credentials containing the
+	// bucket and access keys. It is recommended over setting the keys
+	// directly.
+	Credentials  string            `json:"credentials,omitempty"`
+	Bucket       string            `json:"bucket,omitempty"`
+	BucketRegion string            `json:"bucket_region,omitempty"`
+	Key          string            `json:"key,omitempty"`
+	Secret       string            `json:"secret,omitempty"`
+	Path         string            `json:"path,omitempty"`
+	URLPrefix    string            `json:"url_prefix,omitempty"`
+	ACL          string            `json:"acl,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Host         string            `json:"host,omitempty"`
+}
+
+// Robot implements Step.
+func (step S3Store) Robot() string { return "/s3/store" }
+
+// Map implements Step.
+func (step S3Store) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
@@ -0,0 +1,46 @@
+package robots
+
+// VideoEncode encodes, resizes and applies watermarks to videos.
+// See https://transloadit.com/docs/transcoding/video-encoding/video-encode/
+type VideoEncode struct {
+	Base
+
+	Preset            string                 `json:"preset,omitempty"`
+	Width             int                    `json:"width,omitempty"`
+	Height            int                    `json:"height,omitempty"`
+	ResizeStrategy    string                 `json:"resize_strategy,omitempty"`
+	Zoom              *bool                  `json:"zoom,omitempty"`
+	Background        string                 `json:"background,omitempty"`
+	Turbo             bool                   `json:"turbo,omitempty"`
+	WatermarkURL      string                 `json:"watermark_url,omitempty"`
+	WatermarkPosition string                 `json:"watermark_position,omitempty"`
+	FFmpeg            map[string]interface{} `json:"ffmpeg,omitempty"`
+	FFmpegStack       string                 `json:"ffmpeg_stack,omitempty"`
+}
+
+// Robot implements Step.
+func (step VideoEncode) Robot() string { return "/video/encode" }
+
+// Map implements Step.
+func (step VideoEncode) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
+
+// VideoThumbs extracts thumbnails from videos.
+// See https://transloadit.com/docs/transcoding/video-encoding/video-thumbs/
+type VideoThumbs struct {
+	Base
+
+	Count          int           `json:"count,omitempty"`
+	Offsets        []interface{} `json:"offsets,omitempty"`
+	Format         string        `json:"format,omitempty"`
+	Width          int           `json:"width,omitempty"`
+	Height         int           `json:"height,omitempty"`
+	ResizeStrategy string        `json:"resize_strategy,omitempty"`
+	Background     string        `json:"background,omitempty"`
+	FFmpegStack    string        `json:"ffmpeg_stack,omitempty"`
+}
+
+// Robot implements Step.
+func (step VideoThumbs) Robot() string { return "/video/thumbs" }
+
+// Map implements Step.
+func (step VideoThumbs) Map() map[string]interface{} { return toMap(step.Robot(), step.Base, step) }
@@ -0,0 +1,110 @@
+package transloadit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AssemblyParams contains the assembly instructions signed by SignParams.
+// See https://transloadit.com/docs/topics/assembly-instructions/
+type AssemblyParams struct {
+	// TemplateID specifies an optional template from which the instructions
+	// are read.
+	TemplateID string
+	// NotifyURL specifies a URL to which a request will be sent once the
+	// assembly finishes.
+	NotifyURL string
+	// Fields specifies additional key-value pairs available to the
+	// instructions as assembly variables.
+	Fields map[string]interface{}
+	// Steps specifies the steps in the same format as TemplateContent.Steps.
+	Steps map[string]interface{}
+}
+
+// SignOptions configures the signature created by SignParams.
+type SignOptions struct {
+	// ExpiresIn specifies how long the signature remains valid. Defaults to
+	// one hour if left unset.
+	ExpiresIn time.Duration
+	// Nonce specifies whether a random nonce is added to the params, so that
+	// the API does not reject them as reused if the same instructions are
+	// signed multiple times within one second.
+	Nonce bool
+}
+
+// SignedParams contains the params and their signature, which can be passed
+// to browser uploaders such as Uppy. The JSON encoding matches the
+// `params` and `signature` fields expected by the API.
+type SignedParams struct {
+	Params    string `json:"params"`
+	Signature string `json:"signature"`
+}
+
+// SignParams creates the signed params for starting an assembly with the
+// provided instructions, for example from a browser using Uppy. Since the
+// signature only covers the returned params, they can be handed out to
+// untrusted clients without exposing the AuthSecret and without allowing the
+// instructions to be changed.
+// See https://transloadit.com/docs/topics/signature-authentication/
+func (client *Client) SignParams(params AssemblyParams, options SignOptions) (SignedParams, error) {
+	expiresIn := options.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+
+	content := map[string]interface{}{
+		"auth": authParams{
+			Key:     client.config.AuthKey,
+			Expires: formatExpires(client.clock.Now().Add(expiresIn)),
+		},
+	}
+	if params.TemplateID != "" {
+		content["template_id"] = params.TemplateID
+	}
+	if params.NotifyURL != "" {
+		content["notify_url"] = params.NotifyURL
+	}
+	if len(params.Fields) != 0 {
+		content["fields"] = params.Fields
+	}
+	if len(params.Steps) != 0 {
+		content["steps"] = params.Steps
+	}
+
+	if options.Nonce {
+		// The nonce must not be predictable, since the params are handed out.
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return SignedParams{}, fmt.Errorf("unable to create nonce: %w", err)
+		}
+		content["nonce"] = hex.EncodeToString(nonce)
+	}
+
+	b, err := json.Marshal(content)
+	if err != nil {
+		return SignedParams{}, fmt.Errorf("unable to create signature: %w", err)
+	}
+
+	return SignedParams{
+		Params:    string(b),
+		Signature: client.signature(b),
+	}, nil
+}
+
+// signature returns the sha384 HMAC of the content using the AuthSecret.
+func (client *Client) signature(content []byte) string {
+	hash := hmac.New(sha512.New384, []byte(client.config.AuthSecret))
+	hash.Write(content)
+	return "sha384:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// formatExpires returns the time in the format expected by the API for
+// auth.expires.
+func formatExpires(expires time.Time) string {
+	return expires.UTC().Format("2006/01/02 15:04:05+00:00")
+}
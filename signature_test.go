@@ -0,0 +1,99 @@
+package transloadit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSignParams(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+	})
+
+	signed, err := client.SignParams(AssemblyParams{
+		TemplateID: "template",
+		NotifyURL:  "https://example.com/notify",
+		Fields:     map[string]interface{}{"user": "42"},
+	}, SignOptions{ExpiresIn: 10 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signed.Signature != client.signature([]byte(signed.Params)) {
+		t.Fatal("signature does not match params")
+	}
+
+	var params struct {
+		Auth struct {
+			Key     string `json:"key"`
+			Expires string `json:"expires"`
+		} `json:"auth"`
+		TemplateID string                 `json:"template_id"`
+		NotifyURL  string                 `json:"notify_url"`
+		Fields     map[string]interface{} `json:"fields"`
+		Steps      map[string]interface{} `json:"steps"`
+		Nonce      string                 `json:"nonce"`
+	}
+	if err := json.Unmarshal([]byte(signed.Params), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if params.Auth.Key != "key" || params.TemplateID != "template" || params.NotifyURL != "https://example.com/notify" || params.Fields["user"] != "42" {
+		t.Fatalf("wrong params %s", signed.Params)
+	}
+	if params.Steps != nil || params.Nonce != "" {
+		t.Fatalf("unexpected params %s", signed.Params)
+	}
+
+	expires, err := time.Parse("2006/01/02 15:04:05-07:00", params.Auth.Expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := time.Until(expires); remaining > 10*time.Minute || remaining < 9*time.Minute {
+		t.Fatalf("wrong expiry %s", params.Auth.Expires)
+	}
+}
+
+func TestSignParams_Nonce(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+	})
+
+	params := AssemblyParams{
+		Steps: map[string]interface{}{
+			"resize": map[string]interface{}{"robot": "/image/resize"},
+		},
+	}
+
+	first, err := client.SignParams(params, SignOptions{Nonce: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.SignParams(params, SignOptions{Nonce: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Params == second.Params || first.Signature == second.Signature {
+		t.Fatal("params should differ due to the nonce")
+	}
+
+	b, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["params"] != first.Params || fields["signature"] != first.Signature {
+		t.Fatalf("wrong JSON encoding %s", b)
+	}
+}
@@ -0,0 +1,235 @@
+package transloadit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smartCDNDomain is the domain below which each workspace has its own Smart
+// CDN host.
+const smartCDNDomain = ".tlcdn.com"
+
+var (
+	// ErrSmartCDNUrlExpired is returned by VerifySmartCDNUrl if the URL has a
+	// valid signature but its expiry has passed.
+	ErrSmartCDNUrlExpired = errors.New("transloadit: smart cdn url has expired")
+	// ErrSmartCDNUrlTampered is returned by VerifySmartCDNUrl if the URL's
+	// signature does not match its content, for example because a parameter
+	// was modified or the URL was signed with a different secret.
+	ErrSmartCDNUrlTampered = errors.New("transloadit: smart cdn url signature does not match")
+)
+
+// SignedSmartCDNUrlOptions contains options for creating a signed Smart CDN URL
+type SignedSmartCDNUrlOptions struct {
+	// Workspace slug
+	Workspace string
+	// Template slug or template ID
+	Template string
+	// Input value that is provided as `${fields.input}` in the template
+	Input string
+	// Additional parameters for the URL query string. Can be nil.
+	URLParams url.Values
+	// Expiration timestamp of the signature. Defaults to 1 hour from now if left unset.
+	ExpiresAt time.Time
+}
+
+// CreateSignedSmartCDNUrl constructs a signed Smart CDN URL. It can be
+// verified using VerifySmartCDNUrl.
+// See https://transloadit.com/docs/topics/signature-authentication/#smart-cdn
+func (client *Client) CreateSignedSmartCDNUrl(opts SignedSmartCDNUrlOptions) string {
+	var expiresAt int64
+	if !opts.ExpiresAt.IsZero() {
+		expiresAt = opts.ExpiresAt.Unix() * 1000
+	} else {
+		expiresAt = client.clock.Now().Add(time.Hour).Unix() * 1000 // 1 hour
+	}
+
+	queryParams := make(url.Values, len(opts.URLParams)+2)
+	for key, values := range opts.URLParams {
+		queryParams[key] = values
+	}
+
+	queryParams.Set("auth_key", client.config.AuthKey)
+	queryParams.Set("exp", strconv.FormatInt(expiresAt, 10))
+
+	return client.signSmartCDNUrl(url.PathEscape(opts.Workspace)+smartCDNDomain, opts.Workspace, opts.Template, opts.Input, queryParams)
+}
+
+// signSmartCDNUrl returns the URL for the input on the provided host, signed
+// for the workspace. queryParams must include auth_key and exp.
+func (client *Client) signSmartCDNUrl(host, workspace, template, input string, queryParams url.Values) string {
+	queryString, signature := smartCDNSignature(client.config.AuthSecret, workspace, template, input, queryParams)
+
+	return fmt.Sprintf("https://%s/%s/%s?%s&sig=%s",
+		host, url.PathEscape(template), url.PathEscape(input), queryString, url.QueryEscape(signature))
+}
+
+// SmartCDNUrl contains the components of a signed Smart CDN URL.
+type SmartCDNUrl struct {
+	// Workspace is the workspace slug taken from the URL's host or provided
+	// to VerifySmartCDNUrlForWorkspace.
+	Workspace string
+	// Template is the template slug or template ID.
+	Template string
+	// Input is the unescaped input value.
+	Input string
+	// AuthKey is the key whose secret was used to sign the URL.
+	AuthKey string
+	// Params contains the additional query parameters, excluding auth_key,
+	// exp and sig.
+	Params url.Values
+	// ExpiresAt is the expiry of the signature.
+	ExpiresAt time.Time
+	// Signature is the signature including its algorithm prefix, for example
+	// "sha256:…".
+	Signature string
+}
+
+// ParseSmartCDNUrl extracts the components of a signed Smart CDN URL, as
+// created by Client.CreateSignedSmartCDNUrl, without verifying its signature.
+func ParseSmartCDNUrl(rawURL string) (SmartCDNUrl, error) {
+	return parseSmartCDNUrl(rawURL, "")
+}
+
+// parseSmartCDNUrl implements ParseSmartCDNUrl. If workspace is empty, it is
+// taken from the URL's host, which must be below tlcdn.com. Otherwise, the
+// URL may use any host, such as a custom CNAME.
+func parseSmartCDNUrl(rawURL string, workspace string) (SmartCDNUrl, error) {
+	var parsed SmartCDNUrl
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: %w", err)
+	}
+
+	if workspace == "" {
+		host := u.Hostname()
+		if !strings.HasSuffix(host, smartCDNDomain) || strings.Count(host, ".") != 2 {
+			return parsed, fmt.Errorf("transloadit: invalid smart cdn url: host %q is not below %s", host, smartCDNDomain[1:])
+		}
+		if workspace, err = url.PathUnescape(strings.TrimSuffix(host, smartCDNDomain)); err != nil {
+			return parsed, fmt.Errorf("transloadit: invalid smart cdn url: %w", err)
+		}
+	}
+	parsed.Workspace = workspace
+
+	segments := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+	if len(segments) != 2 || segments[0] == "" {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: path %q must consist of template and input", u.EscapedPath())
+	}
+	if parsed.Template, err = url.PathUnescape(segments[0]); err != nil {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: %w", err)
+	}
+	if parsed.Input, err = url.PathUnescape(segments[1]); err != nil {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: %w", err)
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: %w", err)
+	}
+
+	for _, key := range []string{"auth_key", "exp", "sig"} {
+		if len(query[key]) != 1 {
+			return parsed, fmt.Errorf("transloadit: invalid smart cdn url: query must contain exactly one %s parameter", key)
+		}
+	}
+
+	parsed.AuthKey = query.Get("auth_key")
+	parsed.Signature = query.Get("sig")
+
+	// The expiry is signed as is, so only its canonical form is accepted.
+	expiresAt, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil || strconv.FormatInt(expiresAt, 10) != query.Get("exp") {
+		return parsed, fmt.Errorf("transloadit: invalid smart cdn url: invalid exp parameter %q", query.Get("exp"))
+	}
+	parsed.ExpiresAt = time.Unix(0, expiresAt*int64(time.Millisecond))
+
+	query.Del("auth_key")
+	query.Del("exp")
+	query.Del("sig")
+	parsed.Params = query
+
+	return parsed, nil
+}
+
+// VerifySmartCDNUrl parses the signed Smart CDN URL and verifies its signature
+// using the provided secret. If the signature does not match, an error
+// matching ErrSmartCDNUrlTampered is returned. If the signature matches but
+// has expired, an error matching ErrSmartCDNUrlExpired is returned. In both
+// cases, the parsed URL is returned as well.
+func VerifySmartCDNUrl(secret string, rawURL string) (SmartCDNUrl, error) {
+	return verifySmartCDNUrl(secret, rawURL, "")
+}
+
+// VerifySmartCDNUrlForWorkspace is like VerifySmartCDNUrl but accepts URLs on
+// any host, such as those created using SmartCDNUrlBuilder.Host for a custom
+// CNAME. Since the workspace cannot be derived from such a host, it must be
+// provided.
+func VerifySmartCDNUrlForWorkspace(secret string, workspace string, rawURL string) (SmartCDNUrl, error) {
+	if workspace == "" {
+		return SmartCDNUrl{}, errors.New("transloadit: workspace must not be empty")
+	}
+	return verifySmartCDNUrl(secret, rawURL, workspace)
+}
+
+// verifySmartCDNUrl implements VerifySmartCDNUrl and
+// VerifySmartCDNUrlForWorkspace.
+func verifySmartCDNUrl(secret string, rawURL string, workspace string) (SmartCDNUrl, error) {
+	parsed, err := parseSmartCDNUrl(rawURL, workspace)
+	if err != nil {
+		return parsed, err
+	}
+
+	queryParams := make(url.Values, len(parsed.Params)+2)
+	for key, values := range parsed.Params {
+		queryParams[key] = values
+	}
+	queryParams.Set("auth_key", parsed.AuthKey)
+	queryParams.Set("exp", strconv.FormatInt(parsed.ExpiresAt.UnixNano()/int64(time.Millisecond), 10))
+
+	_, signature := smartCDNSignature(secret, parsed.Workspace, parsed.Template, parsed.Input, queryParams)
+	if !hmac.Equal([]byte(signature), []byte(parsed.Signature)) {
+		return parsed, ErrSmartCDNUrlTampered
+	}
+
+	if !time.Now().Before(parsed.ExpiresAt) {
+		return parsed, ErrSmartCDNUrlExpired
+	}
+
+	return parsed, nil
+}
+
+// smartCDNSignature returns the query string with sorted keys and the
+// signature of a Smart CDN URL. queryParams must include auth_key and exp.
+func smartCDNSignature(secret, workspace, template, input string, queryParams url.Values) (string, string) {
+	queryParamsKeys := make([]string, 0, len(queryParams))
+	for k := range queryParams {
+		queryParamsKeys = append(queryParamsKeys, k)
+	}
+	sort.Strings(queryParamsKeys)
+
+	var queryParts []string
+	for _, k := range queryParamsKeys {
+		for _, v := range queryParams[k] {
+			queryParts = append(queryParts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	queryString := strings.Join(queryParts, "&")
+
+	stringToSign := fmt.Sprintf("%s/%s/%s?%s", url.PathEscape(workspace), url.PathEscape(template), url.PathEscape(input), queryString)
+
+	// Smart CDN signatures intentionally remain SHA-256; API request signatures use SHA-384.
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(stringToSign))
+
+	return queryString, "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,189 @@
+package transloadit
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// workspaceSlugPattern matches workspace slugs, which are used as the
+	// first label of the Smart CDN host.
+	workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9_-]{0,61}[a-z0-9])?$`)
+	// templateSlugPattern matches template slugs and template IDs.
+	templateSlugPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// smartCDNFormatPattern matches the names of output formats.
+	smartCDNFormatPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+// SmartCDNUrlBuilder creates signed Smart CDN URLs for one workspace and
+// template. Its methods return a modified copy of the builder, so a common
+// base can be shared, for example to sign the URLs of all images in a
+// gallery:
+//
+//	thumbnail := client.NewSmartCDNUrlBuilder("my-workspace", "thumbnails").
+//		Width(200).
+//		Format("webp")
+//	for _, name := range names {
+//		signedURL, err := thumbnail.URL(name)
+//		…
+//	}
+//
+// Invalid arguments passed to the builder's methods are reported by URL.
+type SmartCDNUrlBuilder struct {
+	client    *Client
+	workspace string
+	template  string
+	host      string
+	params    []smartCDNParam
+	expiresAt time.Time
+	expiresIn time.Duration
+	err       error
+}
+
+type smartCDNParam struct {
+	key   string
+	value string
+}
+
+// NewSmartCDNUrlBuilder returns a builder for URLs of the template in the
+// workspace. By default, the URLs use the host <workspace>.tlcdn.com and
+// expire one hour after being created.
+func (client *Client) NewSmartCDNUrlBuilder(workspace, template string) SmartCDNUrlBuilder {
+	builder := SmartCDNUrlBuilder{
+		client:    client,
+		workspace: workspace,
+		template:  template,
+		expiresIn: time.Hour,
+	}
+
+	if !workspaceSlugPattern.MatchString(workspace) {
+		builder.err = fmt.Errorf("transloadit: invalid workspace slug %q", workspace)
+	} else if !templateSlugPattern.MatchString(template) {
+		builder.err = fmt.Errorf("transloadit: invalid template slug %q", template)
+	}
+
+	return builder
+}
+
+// Host sets a custom host, such as a CNAME pointing to the Smart CDN, which is
+// used instead of <workspace>.tlcdn.com. The host may include a port. The
+// resulting URLs can be checked using VerifySmartCDNUrlForWorkspace.
+func (builder SmartCDNUrlBuilder) Host(host string) SmartCDNUrlBuilder {
+	u, err := url.Parse("//" + host)
+	if err != nil || u.Host != host || u.User != nil || u.Hostname() == "" {
+		return builder.fail(fmt.Errorf("transloadit: invalid smart cdn host %q", host))
+	}
+
+	builder.host = host
+	return builder
+}
+
+// Width sets the width in pixels to which the input is resized.
+func (builder SmartCDNUrlBuilder) Width(pixels int) SmartCDNUrlBuilder {
+	if pixels <= 0 {
+		return builder.fail(fmt.Errorf("transloadit: smart cdn width must be positive, got %d", pixels))
+	}
+	return builder.Param("width", strconv.Itoa(pixels))
+}
+
+// Height sets the height in pixels to which the input is resized.
+func (builder SmartCDNUrlBuilder) Height(pixels int) SmartCDNUrlBuilder {
+	if pixels <= 0 {
+		return builder.fail(fmt.Errorf("transloadit: smart cdn height must be positive, got %d", pixels))
+	}
+	return builder.Param("height", strconv.Itoa(pixels))
+}
+
+// Format sets the output format, for example "webp", "avif", "jpg" or "png".
+func (builder SmartCDNUrlBuilder) Format(format string) SmartCDNUrlBuilder {
+	if !smartCDNFormatPattern.MatchString(format) {
+		return builder.fail(fmt.Errorf("transloadit: invalid smart cdn format %q", format))
+	}
+	return builder.Param("format", format)
+}
+
+// Quality sets the output quality between 1 and 100.
+func (builder SmartCDNUrlBuilder) Quality(quality int) SmartCDNUrlBuilder {
+	if quality < 1 || quality > 100 {
+		return builder.fail(fmt.Errorf("transloadit: smart cdn quality must be between 1 and 100, got %d", quality))
+	}
+	return builder.Param("quality", strconv.Itoa(quality))
+}
+
+// Param sets an additional query parameter, replacing previous values for the
+// same key. The keys auth_key, exp and sig are reserved for the signature.
+func (builder SmartCDNUrlBuilder) Param(key, value string) SmartCDNUrlBuilder {
+	switch key {
+	case "":
+		return builder.fail(fmt.Errorf("transloadit: smart cdn parameter key must not be empty"))
+	case "auth_key", "exp", "sig":
+		return builder.fail(fmt.Errorf("transloadit: smart cdn parameter %s is reserved", key))
+	}
+
+	// Copy the parameters, so that other copies of the builder are not affected.
+	params := make([]smartCDNParam, 0, len(builder.params)+1)
+	for _, param := range builder.params {
+		if param.key != key {
+			params = append(params, param)
+		}
+	}
+	builder.params = append(params, smartCDNParam{key, value})
+	return builder
+}
+
+// ExpiresAt sets the time at which the signatures expire.
+func (builder SmartCDNUrlBuilder) ExpiresAt(expiresAt time.Time) SmartCDNUrlBuilder {
+	builder.expiresAt = expiresAt
+	builder.expiresIn = 0
+	return builder
+}
+
+// ExpiresIn sets the duration after which the signatures expire, relative to
+// the creation of each URL.
+func (builder SmartCDNUrlBuilder) ExpiresIn(expiresIn time.Duration) SmartCDNUrlBuilder {
+	if expiresIn <= 0 {
+		return builder.fail(fmt.Errorf("transloadit: smart cdn expiry must be positive, got %s", expiresIn))
+	}
+	builder.expiresAt = time.Time{}
+	builder.expiresIn = expiresIn
+	return builder
+}
+
+// URL returns the signed URL for the input value, which is provided as
+// `${fields.input}` in the template. It returns the first error caused by an
+// invalid argument to the builder.
+func (builder SmartCDNUrlBuilder) URL(input string) (string, error) {
+	if builder.err != nil {
+		return "", builder.err
+	}
+
+	expiresAt := builder.expiresAt
+	if builder.expiresIn > 0 {
+		expiresAt = builder.client.clock.Now().Add(builder.expiresIn)
+	}
+
+	queryParams := make(url.Values, len(builder.params)+2)
+	for _, param := range builder.params {
+		queryParams.Set(param.key, param.value)
+	}
+	queryParams.Set("auth_key", builder.client.config.AuthKey)
+	queryParams.Set("exp", strconv.FormatInt(expiresAt.Unix()*1000, 10))
+
+	host := builder.host
+	if host == "" {
+		host = builder.workspace + smartCDNDomain
+	}
+
+	return builder.client.signSmartCDNUrl(host, builder.workspace, builder.template, input, queryParams), nil
+}
+
+// fail records the error, unless an earlier one has been recorded already.
+func (builder SmartCDNUrlBuilder) fail(err error) SmartCDNUrlBuilder {
+	if builder.err == nil {
+		builder.err = err
+	}
+	return builder
+}
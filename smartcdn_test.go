@@ -0,0 +1,284 @@
+package transloadit
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySmartCDNUrl(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+	})
+
+	params := url.Values{}
+	params.Add("foo", "bar")
+	params.Add("aaa", "42")
+	params.Add("aaa", "21")
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	signedURL := client.CreateSignedSmartCDNUrl(SignedSmartCDNUrlOptions{
+		Workspace: "foo_workspace",
+		Template:  "foo_template",
+		Input:     "foo/input image.jpg",
+		URLParams: params,
+		ExpiresAt: expiresAt,
+	})
+
+	parsed, err := VerifySmartCDNUrl("foo_secret", signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Workspace != "foo_workspace" || parsed.Template != "foo_template" || parsed.Input != "foo/input image.jpg" {
+		t.Fatalf("wrong path components %+v", parsed)
+	}
+	if parsed.AuthKey != "foo_key" || !parsed.ExpiresAt.Equal(expiresAt) || !strings.HasPrefix(parsed.Signature, "sha256:") {
+		t.Fatalf("wrong signature components %+v", parsed)
+	}
+	if parsed.Params.Encode() != "aaa=42&aaa=21&foo=bar" {
+		t.Fatalf("wrong params %s", parsed.Params.Encode())
+	}
+
+	// The order of the query parameters is irrelevant.
+	u, _ := url.Parse(signedURL)
+	query := u.Query()
+	u.RawQuery = query.Encode()
+	if _, err := VerifySmartCDNUrl("foo_secret", u.String()); err != nil {
+		t.Fatalf("reordered URL rejected: %s", err)
+	}
+}
+
+func TestVerifySmartCDNUrl_Tampered(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+	})
+
+	signedURL := client.CreateSignedSmartCDNUrl(SignedSmartCDNUrlOptions{
+		Workspace: "foo_workspace",
+		Template:  "foo_template",
+		Input:     "input.jpg",
+		URLParams: url.Values{"width": {"100"}},
+	})
+
+	tamper := func(modify func(u *url.URL, query url.Values)) string {
+		u, _ := url.Parse(signedURL)
+		query := u.Query()
+		modify(u, query)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	tests := map[string]string{
+		"param":     tamper(func(u *url.URL, query url.Values) { query.Set("width", "1000") }),
+		"new param": tamper(func(u *url.URL, query url.Values) { query.Set("height", "100") }),
+		"expiry":    tamper(func(u *url.URL, query url.Values) { query.Set("exp", "99999999999999") }),
+		"input":     tamper(func(u *url.URL, query url.Values) { u.Path = "/foo_template/other.jpg" }),
+		"workspace": tamper(func(u *url.URL, query url.Values) { u.Host = "other.tlcdn.com" }),
+		"signature": tamper(func(u *url.URL, query url.Values) { query.Set("sig", "sha256:00") }),
+	}
+
+	for name, tamperedURL := range tests {
+		if _, err := VerifySmartCDNUrl("foo_secret", tamperedURL); !errors.Is(err, ErrSmartCDNUrlTampered) {
+			t.Errorf("%s: expected tampered error, got %v", name, err)
+		}
+	}
+
+	if _, err := VerifySmartCDNUrl("other_secret", signedURL); !errors.Is(err, ErrSmartCDNUrlTampered) {
+		t.Errorf("wrong secret: expected tampered error, got %v", err)
+	}
+}
+
+func TestVerifySmartCDNUrl_Expired(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+	})
+
+	signedURL := client.CreateSignedSmartCDNUrl(SignedSmartCDNUrlOptions{
+		Workspace: "foo_workspace",
+		Template:  "foo_template",
+		Input:     "input.jpg",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	parsed, err := VerifySmartCDNUrl("foo_secret", signedURL)
+	if !errors.Is(err, ErrSmartCDNUrlExpired) {
+		t.Fatalf("expected expired error, got %v", err)
+	}
+	if parsed.Template != "foo_template" {
+		t.Fatalf("parsed URL not returned: %+v", parsed)
+	}
+}
+
+func TestParseSmartCDNUrl_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, rawURL := range []string{
+		"https://example.com/template/input?auth_key=key&exp=1&sig=sha256%3A00",
+		"https://a.b.tlcdn.com/template/input?auth_key=key&exp=1&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template?auth_key=key&exp=1&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input/more?auth_key=key&exp=1&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input?exp=1&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input?auth_key=key&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input?auth_key=key&exp=1",
+		"https://workspace.tlcdn.com/template/input?auth_key=key&exp=01&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input?auth_key=key&exp=soon&sig=sha256%3A00",
+		"https://workspace.tlcdn.com/template/input?auth_key=key&exp=1&sig=a&sig=b",
+	} {
+		if _, err := ParseSmartCDNUrl(rawURL); err == nil {
+			t.Errorf("expected error for %s", rawURL)
+		}
+	}
+}
+
+func TestSmartCDNUrlBuilder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+		Clock: func() time.Time {
+			return now
+		},
+	})
+
+	// The builder produces the same URLs as CreateSignedSmartCDNUrl.
+	signedURL, err := client.NewSmartCDNUrlBuilder("foo_workspace", "foo_template").
+		Param("foo", "bar").
+		Width(100).
+		Height(50).
+		Format("webp").
+		Quality(80).
+		URL("foo/input")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := client.CreateSignedSmartCDNUrl(SignedSmartCDNUrlOptions{
+		Workspace: "foo_workspace",
+		Template:  "foo_template",
+		Input:     "foo/input",
+		URLParams: url.Values{
+			"foo":     {"bar"},
+			"width":   {"100"},
+			"height":  {"50"},
+			"format":  {"webp"},
+			"quality": {"80"},
+		},
+	})
+	if signedURL != expected {
+		t.Fatalf("Expected URL:\n%s\nGot:\n%s", expected, signedURL)
+	}
+
+	parsed, err := ParseSmartCDNUrl(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("wrong expiry %s", parsed.ExpiresAt)
+	}
+
+	// Modifying a copy of the builder does not affect the original.
+	base := client.NewSmartCDNUrlBuilder("foo_workspace", "foo_template").Width(100)
+	_ = base.Width(200).Format("png")
+	signedURL, err = base.ExpiresAt(now.Add(time.Minute)).URL("image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err = ParseSmartCDNUrl(signedURL); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Params.Encode() != "width=100" || !parsed.ExpiresAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("wrong URL %s", signedURL)
+	}
+}
+
+func TestSmartCDNUrlBuilder_Host(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+	})
+
+	builder := client.NewSmartCDNUrlBuilder("foo_workspace", "foo_template")
+	defaultURL, err := builder.URL("image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customURL, err := builder.Host("cdn.example.com:8443").URL("image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature covers the workspace, not the host.
+	if !strings.HasPrefix(customURL, "https://cdn.example.com:8443/foo_template/image.jpg?") {
+		t.Fatalf("wrong URL %s", customURL)
+	}
+	if strings.TrimPrefix(customURL, "https://cdn.example.com:8443") != strings.TrimPrefix(defaultURL, "https://foo_workspace.tlcdn.com") {
+		t.Fatalf("signatures differ:\n%s\n%s", defaultURL, customURL)
+	}
+	// URLs on custom hosts are verified using the workspace.
+	if _, err := VerifySmartCDNUrl("foo_secret", customURL); err == nil {
+		t.Fatal("expected error for custom host without workspace")
+	}
+	parsed, err := VerifySmartCDNUrlForWorkspace("foo_secret", "foo_workspace", customURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Workspace != "foo_workspace" || parsed.Template != "foo_template" || parsed.Input != "image.jpg" {
+		t.Fatalf("wrong components %+v", parsed)
+	}
+	if _, err := VerifySmartCDNUrlForWorkspace("foo_secret", "other_workspace", customURL); !errors.Is(err, ErrSmartCDNUrlTampered) {
+		t.Fatalf("expected tampered error for wrong workspace, got %v", err)
+	}
+	if _, err := VerifySmartCDNUrlForWorkspace("foo_secret", "", customURL); err == nil {
+		t.Fatal("expected error for empty workspace")
+	}
+}
+
+func TestSmartCDNUrlBuilder_Invalid(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "foo_key",
+		AuthSecret: "foo_secret",
+	})
+
+	builder := client.NewSmartCDNUrlBuilder("workspace", "template")
+	tests := map[string]SmartCDNUrlBuilder{
+		"empty workspace":   client.NewSmartCDNUrlBuilder("", "template"),
+		"invalid workspace": client.NewSmartCDNUrlBuilder("my.workspace", "template"),
+		"uppercase":         client.NewSmartCDNUrlBuilder("Workspace", "template"),
+		"empty template":    client.NewSmartCDNUrlBuilder("workspace", ""),
+		"invalid template":  client.NewSmartCDNUrlBuilder("workspace", "a/b"),
+		"host":              builder.Host("https://cdn.example.com"),
+		"empty host":        builder.Host(""),
+		"width":             builder.Width(0),
+		"height":            builder.Height(-1),
+		"format":            builder.Format("../png"),
+		"quality":           builder.Quality(101),
+		"reserved param":    builder.Param("sig", "x"),
+		"empty param":       builder.Param("", "x"),
+		"expiry":            builder.ExpiresIn(-time.Minute),
+		"first error kept":  builder.Width(0).Width(100),
+	}
+
+	for name, builder := range tests {
+		if _, err := builder.URL("image.jpg"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
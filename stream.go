@@ -0,0 +1,332 @@
+package transloadit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AssemblyEventType identifies the kind of an AssemblyEvent.
+type AssemblyEventType string
+
+const (
+	// EventUploadProgress is emitted when the number of bytes received by
+	// the assembly changes. It is only derived from polled assembly statuses.
+	EventUploadProgress AssemblyEventType = "upload_progress"
+	// EventUploadFinished is emitted for each file that has been uploaded.
+	EventUploadFinished AssemblyEventType = "assembly_upload_finished"
+	// EventUploadingFinished is emitted once all files have been uploaded
+	// and the assembly starts executing.
+	EventUploadingFinished AssemblyEventType = "assembly_uploading_finished"
+	// EventExecutionProgress is emitted while the assembly is executing.
+	EventExecutionProgress AssemblyEventType = "assembly_execution_progress"
+	// EventResultFinished is emitted for each result file of a step.
+	EventResultFinished AssemblyEventType = "assembly_result_finished"
+	// EventFinished is emitted once the assembly has finished executing.
+	EventFinished AssemblyEventType = "assembly_finished"
+	// EventError is emitted if the assembly failed or its status could not
+	// be retrieved.
+	EventError AssemblyEventType = "assembly_error"
+)
+
+// AssemblyEvent describes a change in an assembly's status.
+type AssemblyEvent struct {
+	Type AssemblyEventType
+	// Step is the step name for EventResultFinished.
+	Step string
+	// File is the uploaded or resulting file for EventUploadFinished and
+	// EventResultFinished.
+	File *FileInfo
+	// BytesReceived and BytesExpected are set for EventUploadProgress.
+	BytesReceived int
+	BytesExpected int
+	// Progress is the combined progress of all steps in percent for
+	// EventExecutionProgress.
+	Progress float64
+	// Info is the full assembly status for EventFinished and EventError. It
+	// is nil if the status could not be retrieved.
+	Info *AssemblyInfo
+	// Err describes the failure for EventError.
+	Err error
+}
+
+// streamPollInterval is the interval for fetching the assembly status if the
+// update stream is not available.
+const streamPollInterval = time.Second
+
+// StreamAssembly emits events about the progress of the provided assembly
+// on the returned channel. The events are received from the Server-Sent
+// Events feed available at AssemblyInfo.UpdateStreamURL. If the assembly
+// has no such URL or the stream cannot be consumed, the assembly status is
+// polled instead and the events are derived from the changes between two
+// statuses.
+//
+// The channel is closed after an EventFinished or EventError event has been
+// sent, or when the context is canceled. Consumers must drain the channel
+// or cancel the context to release all resources.
+func (client *Client) StreamAssembly(ctx context.Context, assembly *AssemblyInfo) <-chan AssemblyEvent {
+	events := make(chan AssemblyEvent, 16)
+
+	go func() {
+		defer close(events)
+
+		stream := assemblyStream{
+			client:   client,
+			ctx:      ctx,
+			events:   events,
+			url:      assembly.AssemblySSLURL,
+			uploads:  make(map[string]bool),
+			results:  make(map[string]bool),
+			received: -1,
+		}
+
+		if assembly.UpdateStreamURL != "" {
+			if done := stream.consume(assembly.UpdateStreamURL); done {
+				return
+			}
+		}
+
+		stream.poll()
+	}()
+
+	return events
+}
+
+// assemblyStream keeps track of the events which have already been emitted,
+// so that switching from the update stream to polling does not emit them
+// a second time.
+type assemblyStream struct {
+	client *Client
+	ctx    context.Context
+	events chan<- AssemblyEvent
+	url    string
+
+	uploads  map[string]bool
+	results  map[string]bool
+	received int
+}
+
+// emit sends the event and reports whether the consumer is still interested.
+func (stream *assemblyStream) emit(event AssemblyEvent) bool {
+	select {
+	case stream.events <- event:
+		return true
+	case <-stream.ctx.Done():
+		return false
+	}
+}
+
+// consume reads the update stream and reports whether the stream has ended
+// with a final event or the context has been canceled. Otherwise the caller
+// should fall back to polling.
+func (stream *assemblyStream) consume(streamURL string) bool {
+	req, err := http.NewRequest("GET", streamURL, nil)
+	if err != nil {
+		return false
+	}
+	req = req.WithContext(stream.ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Transloadit-Client", "go-sdk:"+Version)
+
+	res, err := stream.client.httpClient.Do(req)
+	if err != nil {
+		return stream.ctx.Err() != nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+
+	done := false
+	readServerSentEvents(res.Body, func(name, data string) bool {
+		done = stream.handle(name, data)
+		return !done
+	})
+
+	return done || stream.ctx.Err() != nil
+}
+
+// handle processes a single event from the update stream and reports whether
+// the stream has ended.
+func (stream *assemblyStream) handle(name, data string) bool {
+	if name == "message" {
+		// Some events are sent as plain messages with the name as data.
+		name = data
+		data = ""
+	}
+
+	switch AssemblyEventType(name) {
+	case EventUploadFinished:
+		var file FileInfo
+		if err := json.Unmarshal([]byte(data), &file); err != nil || stream.uploads[file.ID] {
+			return false
+		}
+		stream.uploads[file.ID] = true
+		return !stream.emit(AssemblyEvent{Type: EventUploadFinished, File: &file})
+	case EventUploadingFinished:
+		return !stream.emit(AssemblyEvent{Type: EventUploadingFinished})
+	case EventExecutionProgress:
+		var details struct {
+			ProgressCombined float64 `json:"progress_combined"`
+		}
+		if err := json.Unmarshal([]byte(data), &details); err != nil {
+			return false
+		}
+		return !stream.emit(AssemblyEvent{Type: EventExecutionProgress, Progress: details.ProgressCombined})
+	case EventResultFinished:
+		var step string
+		var file FileInfo
+		payload := []interface{}{&step, &file}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil || stream.results[resultKey(step, &file)] {
+			return false
+		}
+		stream.results[resultKey(step, &file)] = true
+		return !stream.emit(AssemblyEvent{Type: EventResultFinished, Step: step, File: &file})
+	case EventFinished, EventError:
+		// Fetch the final status, which also contains all details about an
+		// error, and emit the remaining events.
+		stream.poll()
+		return true
+	}
+
+	return false
+}
+
+// poll fetches the assembly status until the assembly has finished.
+func (stream *assemblyStream) poll() {
+	for {
+		if done := stream.pollOnce(); done {
+			return
+		}
+
+		select {
+		case <-stream.ctx.Done():
+			return
+		case <-time.After(streamPollInterval):
+		}
+	}
+}
+
+// pollOnce fetches the assembly status, emits events for all changes and
+// reports whether the assembly has finished.
+func (stream *assemblyStream) pollOnce() bool {
+	info, err := stream.client.GetAssembly(stream.ctx, stream.url)
+	if err != nil {
+		if stream.ctx.Err() == nil {
+			stream.emit(AssemblyEvent{Type: EventError, Err: err})
+		}
+		return true
+	}
+
+	if info.BytesReceived != stream.received && info.BytesExpected > 0 {
+		stream.received = info.BytesReceived
+		if !stream.emit(AssemblyEvent{
+			Type:          EventUploadProgress,
+			BytesReceived: info.BytesReceived,
+			BytesExpected: int(info.BytesExpected),
+		}) {
+			return true
+		}
+	}
+
+	for _, file := range info.Uploads {
+		if stream.uploads[file.ID] {
+			continue
+		}
+		stream.uploads[file.ID] = true
+		if !stream.emit(AssemblyEvent{Type: EventUploadFinished, File: file}) {
+			return true
+		}
+	}
+
+	for step, files := range info.Results {
+		for _, file := range files {
+			if stream.results[resultKey(step, file)] {
+				continue
+			}
+			stream.results[resultKey(step, file)] = true
+			if !stream.emit(AssemblyEvent{Type: EventResultFinished, Step: step, File: file}) {
+				return true
+			}
+		}
+	}
+
+	if info.Error != "" {
+		stream.emit(AssemblyEvent{
+			Type: EventError,
+			Info: info,
+			Err:  newAssemblyError(info),
+		})
+		return true
+	}
+
+	if AssemblyFinished(info) {
+		stream.emit(AssemblyEvent{Type: EventFinished, Info: info})
+		return true
+	}
+
+	return false
+}
+
+// resultKey identifies a result file. The step is included since steps such
+// as /file/filter pass files on without changing their IDs.
+func resultKey(step string, file *FileInfo) string {
+	id := file.ID
+	if id == "" {
+		id = file.SSLURL
+	}
+	return step + "/" + id
+}
+
+// readServerSentEvents parses the event stream and invokes handle for each
+// event until it returns false or the stream ends.
+// See https://html.spec.whatwg.org/multipage/server-sent-events.html
+func readServerSentEvents(r io.Reader, handle func(name, data string) bool) error {
+	reader := bufio.NewReader(r)
+	name := ""
+	var data []string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if len(data) != 0 {
+				if name == "" {
+					name = "message"
+				}
+				if !handle(name, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			name = ""
+			data = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i != -1 {
+			field = line[:i]
+			value = strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package transloadit_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+// newStreamTestServer returns a server which serves the provided event
+// stream at /stream and the final status at /assemblies/a1. The fake server
+// is not used since it does not offer an update stream.
+func newStreamTestServer(t *testing.T, stream, status string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stream":
+			if r.Header.Get("Accept") != "text/event-stream" {
+				t.Errorf("wrong Accept header %q", r.Header.Get("Accept"))
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, stream)
+		case "/assemblies/a1":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, status)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func collectEvents(events <-chan transloadit.AssemblyEvent) []transloadit.AssemblyEvent {
+	var collected []transloadit.AssemblyEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	return collected
+}
+
+func eventTypes(events []transloadit.AssemblyEvent) []transloadit.AssemblyEventType {
+	types := make([]transloadit.AssemblyEventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestStreamAssembly_ServerSentEvents(t *testing.T) {
+	t.Parallel()
+
+	stream := strings.Join([]string{
+		": keep-alive",
+		"",
+		"event: assembly_upload_finished",
+		`data: {"id":"u1","name":"lol_cat.jpg"}`,
+		"",
+		"data: assembly_uploading_finished",
+		"",
+		"event: assembly_execution_progress",
+		`data: {"progress_combined":50}`,
+		"",
+		"event: assembly_result_finished",
+		`data: ["resize",{"id":"r1","ssl_url":"https://example.com/r1.jpg"}]`,
+		"",
+		"event: assembly_result_finished",
+		`data: ["resize",{"id":"r1","ssl_url":"https://example.com/r1.jpg"}]`,
+		"",
+		"data: assembly_finished",
+		"",
+		"event: assembly_error",
+		"data: {}",
+		"",
+	}, "\n")
+
+	server := newStreamTestServer(t, stream,
+		`{"ok":"ASSEMBLY_COMPLETED","assembly_id":"a1","uploads":[{"id":"u1"}],"results":{"resize":[{"id":"r1"}],"thumbs":[{"id":"r2"}]}}`,
+	)
+	defer server.Close()
+
+	client := transloadit.NewClient(transloadit.Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	events := collectEvents(client.StreamAssembly(context.Background(), &transloadit.AssemblyInfo{
+		AssemblySSLURL:  server.URL + "/assemblies/a1",
+		UpdateStreamURL: server.URL + "/stream",
+	}))
+
+	expected := []transloadit.AssemblyEventType{
+		transloadit.EventUploadFinished,
+		transloadit.EventUploadingFinished,
+		transloadit.EventExecutionProgress,
+		transloadit.EventResultFinished,
+		transloadit.EventResultFinished,
+		transloadit.EventFinished,
+	}
+	if types := eventTypes(events); !reflect.DeepEqual(types, expected) {
+		t.Fatalf("wrong events %v", types)
+	}
+
+	if events[0].File.Name != "lol_cat.jpg" {
+		t.Fatalf("wrong upload %+v", events[0].File)
+	}
+	if events[2].Progress != 50 {
+		t.Fatalf("wrong progress %f", events[2].Progress)
+	}
+	if events[3].Step != "resize" || events[3].File.SSLURL != "https://example.com/r1.jpg" {
+		t.Fatalf("wrong result %+v", events[3])
+	}
+	// The second result is only contained in the final status.
+	if events[4].Step != "thumbs" || events[4].File.ID != "r2" {
+		t.Fatalf("wrong result %+v", events[4])
+	}
+	if events[5].Info == nil || events[5].Info.Ok != "ASSEMBLY_COMPLETED" {
+		t.Fatal("finished event should contain the final status")
+	}
+}
+
+func TestStreamAssembly_PollingFallback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(
+		transloadittest.StateUploading,
+		transloadittest.StateExecuting,
+		transloadittest.AssemblyState{Ok: "REQUEST_ABORTED", Error: "INVALID_FILE_META_DATA", Message: "broken"},
+	)
+
+	client := transloadit.NewClient(server.Config())
+	assembly := transloadit.NewAssembly()
+	assembly.AddReader("image", "lol_cat.jpg", ioutil.NopCloser(bytes.NewReader(make([]byte, 20))))
+	info, err := client.StartAssembly(ctx, assembly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddResult(info.AssemblyID, "resize", transloadit.FileInfo{Name: "lol_cat.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake server does not offer an update stream, so the status is
+	// polled instead.
+	info.UpdateStreamURL = server.URL + "/stream"
+	events := collectEvents(client.StreamAssembly(ctx, info))
+
+	expected := []transloadit.AssemblyEventType{
+		transloadit.EventUploadProgress,
+		transloadit.EventUploadFinished,
+		transloadit.EventResultFinished,
+		transloadit.EventError,
+	}
+	if types := eventTypes(events); !reflect.DeepEqual(types, expected) {
+		t.Fatalf("wrong events %v", types)
+	}
+
+	if events[0].BytesReceived != 20 || events[0].BytesExpected != 20 {
+		t.Fatalf("wrong progress %+v", events[0])
+	}
+	if events[1].File.Name != "lol_cat.jpg" || events[2].Step != "resize" {
+		t.Fatalf("wrong files %+v %+v", events[1], events[2])
+	}
+	if events[3].Err == nil || events[3].Info == nil || events[3].Info.Error != "INVALID_FILE_META_DATA" {
+		t.Fatalf("wrong error event %+v", events[3])
+	}
+}
+
+func TestStreamAssembly_Cancel(t *testing.T) {
+	t.Parallel()
+
+	// The stream never ends, so only canceling the context stops it.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: assembly_uploading_finished\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := transloadit.NewClient(transloadit.Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.StreamAssembly(ctx, &transloadit.AssemblyInfo{
+		AssemblySSLURL:  server.URL + "/assemblies/a1",
+		UpdateStreamURL: server.URL + "/stream",
+	})
+
+	if event := <-events; event.Type != transloadit.EventUploadingFinished {
+		t.Fatalf("wrong event %v", event.Type)
+	}
+
+	cancel()
+	for range events {
+	}
+}
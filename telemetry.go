@@ -0,0 +1,276 @@
+package transloadit
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the histograms recorded using Config.Meter.
+const (
+	// MetricRequestDuration records the duration of API calls in seconds,
+	// including retries and file uploads.
+	MetricRequestDuration = "transloadit.client.request.duration"
+	// MetricUploadSize records the number of bytes uploaded by
+	// Client.StartAssembly.
+	MetricUploadSize = "transloadit.client.upload.size"
+)
+
+// Keys of the attributes added to spans and measurements.
+const (
+	AttributeMethod       = "http.request.method"
+	AttributePath         = "url.path"
+	AttributeRoute        = "transloadit.route"
+	AttributeStatusCode   = "http.response.status_code"
+	AttributeErrorCode    = "transloadit.error_code"
+	AttributeAssemblyID   = "transloadit.assembly_id"
+	AttributeUploadedSize = "transloadit.upload.size"
+)
+
+// Attribute is a key-value pair describing a span or measurement. Value is a
+// string, int64 or float64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer creates a span for every API call. The client does not depend on a
+// tracing library, so bridging it to one, such as OpenTelemetry, is left to
+// the caller. A complete adapter for OpenTelemetry's trace and metric APIs is:
+//
+//	import (
+//		"context"
+//
+//		transloadit "github.com/transloadit/go-sdk"
+//		"go.opentelemetry.io/otel/attribute"
+//		"go.opentelemetry.io/otel/codes"
+//		"go.opentelemetry.io/otel/metric"
+//		"go.opentelemetry.io/otel/trace"
+//	)
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs ...transloadit.Attribute) (context.Context, transloadit.Span) {
+//		ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(otelAttributes(attrs)...))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attrs ...transloadit.Attribute) {
+//		s.span.SetAttributes(otelAttributes(attrs)...)
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.span.RecordError(err)
+//		s.span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.span.End() }
+//
+//	type otelMeter struct{ meter metric.Meter }
+//
+//	func (m otelMeter) RecordHistogram(ctx context.Context, name string, value float64, attrs ...transloadit.Attribute) {
+//		histogram, err := m.meter.Float64Histogram(name)
+//		if err == nil {
+//			histogram.Record(ctx, value, metric.WithAttributes(otelAttributes(attrs)...))
+//		}
+//	}
+//
+//	func otelAttributes(attrs []transloadit.Attribute) []attribute.KeyValue {
+//		kvs := make([]attribute.KeyValue, 0, len(attrs))
+//		for _, attr := range attrs {
+//			switch value := attr.Value.(type) {
+//			case string:
+//				kvs = append(kvs, attribute.String(attr.Key, value))
+//			case int64:
+//				kvs = append(kvs, attribute.Int64(attr.Key, value))
+//			case float64:
+//				kvs = append(kvs, attribute.Float64(attr.Key, value))
+//			}
+//		}
+//		return kvs
+//	}
+//
+// The adapters are enabled using the global providers of the
+// go.opentelemetry.io/otel package:
+//
+//	config.Tracer = otelTracer{otel.Tracer("transloadit")}
+//	config.Meter = otelMeter{otel.Meter("transloadit")}
+//
+// The returned context is used for the requests belonging to the span, so
+// propagators, for example from a Config.Middleware, can access the span.
+type Tracer interface {
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+// Span represents a single API call started by a Tracer.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	// RecordError is invoked if the call failed, before End.
+	RecordError(err error)
+	End()
+}
+
+// Meter records the values of histograms, such as MetricRequestDuration. See
+// Tracer for an adapter to OpenTelemetry.
+type Meter interface {
+	RecordHistogram(ctx context.Context, name string, value float64, attributes ...Attribute)
+}
+
+// operationKey is the context key for the operation of an API call.
+type operationKey struct{}
+
+// operation collects the telemetry of an API call while it is executed.
+type operation struct {
+	client     *Client
+	ctx        context.Context
+	span       Span
+	start      time.Time
+	attributes []Attribute
+
+	mu         sync.Mutex
+	statusCode int
+	uploaded   int64
+	assemblyID string
+}
+
+// startOperation starts a span for the API call unless neither Config.Tracer
+// nor Config.Meter are set, in which case the operation is nil. The returned
+// context must be used for the call's requests.
+func (client *Client) startOperation(ctx context.Context, method, uri string) (context.Context, *operation) {
+	tracer, meter := client.config.Tracer, client.config.Meter
+	if tracer == nil && meter == nil {
+		return ctx, nil
+	}
+
+	path := uri
+	if u, err := url.Parse(uri); err == nil {
+		path = u.Path
+	}
+	path = "/" + strings.TrimPrefix(path, "/")
+
+	// The route omits identifiers, keeping the number of span names and
+	// metric series low.
+	route := "/" + strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+
+	op := &operation{
+		client: client,
+		start:  time.Now(),
+		attributes: []Attribute{
+			{AttributeMethod, method},
+			{AttributeRoute, route},
+		},
+	}
+
+	if tracer != nil {
+		ctx, op.span = tracer.Start(ctx, method+" "+route,
+			Attribute{AttributeMethod, method},
+			Attribute{AttributePath, path},
+			Attribute{AttributeRoute, route},
+		)
+	}
+
+	op.ctx = context.WithValue(ctx, operationKey{}, op)
+	return op.ctx, op
+}
+
+// operationFromContext returns the operation of the API call which the
+// context belongs to, if any.
+func operationFromContext(ctx context.Context) *operation {
+	op, _ := ctx.Value(operationKey{}).(*operation)
+	return op
+}
+
+// setStatusCode records the status code of the call's last response.
+func (op *operation) setStatusCode(statusCode int) {
+	if op == nil {
+		return
+	}
+	op.mu.Lock()
+	op.statusCode = statusCode
+	op.mu.Unlock()
+}
+
+// addUploaded adds to the number of bytes uploaded during the call.
+func (op *operation) addUploaded(n int64) {
+	if op == nil {
+		return
+	}
+	op.mu.Lock()
+	op.uploaded += n
+	op.mu.Unlock()
+}
+
+// setAssemblyID records the assembly which the call refers to.
+func (op *operation) setAssemblyID(assemblyID string) {
+	if op == nil || assemblyID == "" {
+		return
+	}
+	op.mu.Lock()
+	op.assemblyID = assemblyID
+	op.mu.Unlock()
+}
+
+// end finishes the span and records the call's metrics.
+func (op *operation) end(err error) {
+	if op == nil {
+		return
+	}
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	var errorCode string
+	var reqErr RequestError
+	var assemblyErr AssemblyError
+	if errors.As(err, &reqErr) {
+		errorCode = reqErr.Code
+		if op.assemblyID == "" {
+			op.assemblyID = reqErr.AssemblyID
+		}
+	} else if errors.As(err, &assemblyErr) {
+		errorCode = assemblyErr.Code
+		if op.assemblyID == "" {
+			op.assemblyID = assemblyErr.AssemblyID
+		}
+	}
+
+	var result []Attribute
+	if op.statusCode != 0 {
+		result = append(result, Attribute{AttributeStatusCode, int64(op.statusCode)})
+	}
+	if errorCode != "" {
+		result = append(result, Attribute{AttributeErrorCode, errorCode})
+	}
+
+	// Metrics only receive attributes with a low number of distinct values.
+	if meter := op.client.config.Meter; meter != nil {
+		attributes := append(append([]Attribute{}, op.attributes...), result...)
+		meter.RecordHistogram(op.ctx, MetricRequestDuration, time.Since(op.start).Seconds(), attributes...)
+		if op.uploaded > 0 {
+			meter.RecordHistogram(op.ctx, MetricUploadSize, float64(op.uploaded), attributes...)
+		}
+	}
+
+	if op.span == nil {
+		return
+	}
+
+	if op.assemblyID != "" {
+		result = append(result, Attribute{AttributeAssemblyID, op.assemblyID})
+	}
+	if op.uploaded > 0 {
+		result = append(result, Attribute{AttributeUploadedSize, op.uploaded})
+	}
+	if len(result) != 0 {
+		op.span.SetAttributes(result...)
+	}
+	if err != nil {
+		op.span.RecordError(err)
+	}
+	op.span.End()
+}
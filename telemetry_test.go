@@ -0,0 +1,162 @@
+package transloadit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+func TestTelemetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	recorder := transloadittest.NewRecorder()
+	config := server.Config()
+	config.Tracer = recorder
+	config.Meter = recorder
+	client := transloadit.NewClient(config)
+
+	assembly := transloadit.NewAssembly()
+	assembly.AddReader("image", "image.jpg", ioutil.NopCloser(bytes.NewReader(make([]byte, 1000))))
+	assembly.AddStep("resize", map[string]interface{}{"robot": "/image/resize"})
+	info, err := client.StartAssembly(ctx, assembly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetAssembly(ctx, info.AssemblySSLURL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListTemplates(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %+v", spans)
+	}
+
+	expected := []struct {
+		name       string
+		path       string
+		assemblyID string
+		uploaded   interface{}
+	}{
+		{"POST /assemblies", "/assemblies", info.AssemblyID, int64(1000)},
+		{"GET /assemblies", "/assemblies/" + info.AssemblyID, info.AssemblyID, nil},
+		{"GET /templates", "/templates", "", nil},
+	}
+	for i, want := range expected {
+		span := spans[i]
+		if span.Name != want.name || !span.Ended || len(span.Errors) != 0 {
+			t.Errorf("span %d: unexpected span %+v", i, span)
+		}
+		if span.Attributes[transloadit.AttributePath] != want.path {
+			t.Errorf("span %d: wrong path %v", i, span.Attributes[transloadit.AttributePath])
+		}
+		if span.Attributes[transloadit.AttributeStatusCode] != int64(200) {
+			t.Errorf("span %d: wrong status code %v", i, span.Attributes[transloadit.AttributeStatusCode])
+		}
+		if id, _ := span.Attributes[transloadit.AttributeAssemblyID].(string); id != want.assemblyID {
+			t.Errorf("span %d: wrong assembly ID %q", i, id)
+		}
+		if span.Attributes[transloadit.AttributeUploadedSize] != want.uploaded {
+			t.Errorf("span %d: wrong upload size %v", i, span.Attributes[transloadit.AttributeUploadedSize])
+		}
+	}
+
+	var durations, sizes []transloadittest.Measurement
+	for _, measurement := range recorder.Measurements() {
+		switch measurement.Name {
+		case transloadit.MetricRequestDuration:
+			durations = append(durations, measurement)
+		case transloadit.MetricUploadSize:
+			sizes = append(sizes, measurement)
+		}
+	}
+	if len(durations) != 3 || durations[0].Value <= 0 {
+		t.Fatalf("wrong durations %+v", durations)
+	}
+	if durations[1].Attributes[transloadit.AttributeRoute] != "/assemblies" || durations[1].Attributes[transloadit.AttributeMethod] != "GET" {
+		t.Fatalf("wrong duration attributes %+v", durations[1].Attributes)
+	}
+	if _, ok := durations[1].Attributes[transloadit.AttributePath]; ok {
+		t.Fatal("metrics must not contain the path")
+	}
+	if len(sizes) != 1 || sizes[0].Value != 1000 {
+		t.Fatalf("wrong upload sizes %+v", sizes)
+	}
+}
+
+func TestTelemetry_Error(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	recorder := transloadittest.NewRecorder()
+	config := server.Config()
+	config.Tracer = recorder
+	client := transloadit.NewClient(config)
+
+	server.InjectFault(transloadittest.Fault{
+		Method:     "GET",
+		Path:       "/templates/*",
+		StatusCode: 404,
+		Code:       "TEMPLATE_NOT_FOUND",
+		Message:    "The template was not found.",
+	})
+
+	_, err := client.GetTemplate(ctx, "missing")
+	if !errors.Is(err, transloadit.ErrNotFound) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %+v", spans)
+	}
+	span := spans[0]
+	if span.Name != "GET /templates" || !span.Ended {
+		t.Fatalf("unexpected span %+v", span)
+	}
+	if len(span.Errors) != 1 || !errors.Is(span.Errors[0], transloadit.ErrNotFound) {
+		t.Fatalf("error not recorded %v", span.Errors)
+	}
+	if span.Attributes[transloadit.AttributeStatusCode] != int64(404) || span.Attributes[transloadit.AttributeErrorCode] != "TEMPLATE_NOT_FOUND" {
+		t.Fatalf("wrong attributes %v", span.Attributes)
+	}
+}
+
+func TestTelemetry_Parent(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	recorder := transloadittest.NewRecorder()
+	config := server.Config()
+	config.Tracer = recorder
+	client := transloadit.NewClient(config)
+
+	// Spans of API calls are children of the caller's span.
+	ctx, span := recorder.Start(context.Background(), "handler")
+	if _, err := client.ListAssemblies(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	spans := recorder.Spans()
+	if len(spans) != 2 || spans[1].Name != "GET /assemblies" || spans[1].Parent != "handler" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
@@ -0,0 +1,240 @@
+package transloadit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TemplateSyncOptions configures how SyncTemplates converges the remote
+// templates.
+type TemplateSyncOptions struct {
+	// Delete specifies whether remote templates without a corresponding file
+	// are deleted. By default, they are left untouched.
+	Delete bool
+	// DryRun specifies whether the changes are only computed and returned
+	// without being applied.
+	DryRun bool
+}
+
+// TemplateChangeAction describes how a template is changed by SyncTemplates.
+type TemplateChangeAction string
+
+const (
+	// TemplateCreate is used for files without a remote template.
+	TemplateCreate TemplateChangeAction = "create"
+	// TemplateUpdate is used for remote templates whose content differs
+	// from the file.
+	TemplateUpdate TemplateChangeAction = "update"
+	// TemplateDelete is used for remote templates without a file, if
+	// TemplateSyncOptions.Delete is set.
+	TemplateDelete TemplateChangeAction = "delete"
+	// TemplateUnchanged is used for remote templates matching the file.
+	TemplateUnchanged TemplateChangeAction = "unchanged"
+)
+
+// TemplateChange describes the change of a single template.
+type TemplateChange struct {
+	Action TemplateChangeAction `json:"action"`
+	// Name is the template name, which is derived from the file name.
+	Name string `json:"name"`
+	// ID is the ID of the remote template. It is empty for templates which
+	// are created during a dry run.
+	ID string `json:"id"`
+	// Diff lists the differences between the remote and the local content,
+	// one line per changed key, prefixed with +, - or ~ for added, removed
+	// and modified keys.
+	Diff []string `json:"diff"`
+}
+
+// SyncTemplates makes the remote templates match the template files in the
+// provided directory. Each file named <name>.json contains the content of
+// the template with the same name, i.e. the steps and additional properties,
+// in the same format as TemplateContent. Templates are created if they do not
+// exist and updated if their content differs. The returned changes are
+// sorted by template name and include unchanged templates.
+//
+// If an error occurs while applying the changes, the changes applied so far
+// are returned alongside the error.
+func (client *Client) SyncTemplates(ctx context.Context, dir string, options TemplateSyncOptions) ([]TemplateChange, error) {
+	local, err := readTemplateDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string]Template)
+	it := client.Templates(ctx, &ListOptions{})
+	for it.Next() {
+		template := it.Item()
+		if _, ok := remote[template.Name]; ok {
+			return nil, fmt.Errorf("failed to sync templates: multiple templates are named %s", template.Name)
+		}
+		remote[template.Name] = template
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(local)+len(remote))
+	for name := range local {
+		names = append(names, name)
+	}
+	for name := range remote {
+		if _, ok := local[name]; !ok && options.Delete {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]TemplateChange, 0, len(names))
+	for _, name := range names {
+		content, exists := local[name]
+		template, existsRemotely := remote[name]
+		change := TemplateChange{Name: name, ID: template.ID}
+
+		switch {
+		case !existsRemotely:
+			change.Action = TemplateCreate
+			change.Diff = diffTemplateContent(TemplateContent{}, content)
+			if !options.DryRun {
+				template = NewTemplate()
+				template.Name = name
+				template.Content = content
+				if change.ID, err = client.CreateTemplate(ctx, template); err != nil {
+					return changes, err
+				}
+			}
+		case !exists:
+			change.Action = TemplateDelete
+			change.Diff = diffTemplateContent(template.Content, TemplateContent{})
+			if !options.DryRun {
+				if err := client.DeleteTemplate(ctx, template.ID); err != nil {
+					return changes, err
+				}
+			}
+		default:
+			// The list may not contain the entire content.
+			if template, err = client.GetTemplate(ctx, template.ID); err != nil {
+				return changes, err
+			}
+
+			change.Diff = diffTemplateContent(template.Content, content)
+			change.Action = TemplateUnchanged
+			if len(change.Diff) != 0 {
+				change.Action = TemplateUpdate
+				if !options.DryRun {
+					template.Content = content
+					if err := client.UpdateTemplate(ctx, template.ID, template); err != nil {
+						return changes, err
+					}
+				}
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// readTemplateDir reads the content of all JSON files in the directory,
+// keyed by the file name without extension.
+func readTemplateDir(dir string) (map[string]TemplateContent, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]TemplateContent, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+
+		var content TemplateContent
+		if err := json.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		templates[name] = content
+	}
+
+	return templates, nil
+}
+
+// diffTemplateContent returns the differences between the steps and
+// additional properties of both contents.
+func diffTemplateContent(from, to TemplateContent) []string {
+	var diff []string
+	diff = diffMaps(diff, "steps.", normalizeJSON(from.Steps), normalizeJSON(to.Steps))
+	diff = diffMaps(diff, "", normalizeJSON(from.AdditionalProperties), normalizeJSON(to.AdditionalProperties))
+	return diff
+}
+
+// diffMaps appends the differences between both objects to diff, comparing
+// them key by key. The keys are prefixed with the provided path.
+func diffMaps(diff []string, path string, from, to map[string]interface{}) []string {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+
+		switch {
+		case !inFrom:
+			diff = append(diff, fmt.Sprintf("+ %s%s: %s", path, key, jsonString(toValue)))
+		case !inTo:
+			diff = append(diff, fmt.Sprintf("- %s%s: %s", path, key, jsonString(fromValue)))
+		default:
+			fromMap, fromIsMap := fromValue.(map[string]interface{})
+			toMap, toIsMap := toValue.(map[string]interface{})
+			if fromIsMap && toIsMap {
+				diff = diffMaps(diff, path+key+".", fromMap, toMap)
+			} else if !reflect.DeepEqual(fromValue, toValue) {
+				diff = append(diff, fmt.Sprintf("~ %s%s: %s -> %s", path, key, jsonString(fromValue), jsonString(toValue)))
+			}
+		}
+	}
+
+	return diff
+}
+
+// normalizeJSON converts the values into the types used by encoding/json
+// when decoding into an interface{}, so that they can be compared regardless
+// of their origin.
+func normalizeJSON(values map[string]interface{}) map[string]interface{} {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return values
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return values
+	}
+	return normalized
+}
+
+// jsonString returns the compact JSON encoding of the value.
+func jsonString(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
@@ -0,0 +1,123 @@
+package transloadit_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+func TestSyncTemplates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+	client := transloadit.NewClient(server.Config())
+
+	for name, steps := range map[string]map[string]interface{}{
+		"thumbnails": {"resize": map[string]interface{}{"robot": "/image/resize", "width": 75}},
+		"unchanged":  {"encode": map[string]interface{}{"robot": "/video/encode"}},
+		"obsolete":   {"import": map[string]interface{}{"robot": "/http/import"}},
+	} {
+		template := transloadit.NewTemplate()
+		template.Name = name
+		template.Content.Steps = steps
+		template.RequireSignatureAuth = name == "thumbnails"
+		if _, err := client.CreateTemplate(ctx, template); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "transloadit-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"thumbnails.json": `{"steps":{"resize":{"robot":"/image/resize","width":100},"optimize":{"robot":"/image/optimize"}},"notify_url":"https://example.com"}`,
+		"unchanged.json":  `{"steps":{"encode":{"robot":"/video/encode"}}}`,
+		"new.json":        `{"steps":{"filter":{"robot":"/file/filter"}}}`,
+		"ignored.txt":     `not a template`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []struct {
+		action transloadit.TemplateChangeAction
+		name   string
+		diff   []string
+	}{
+		{transloadit.TemplateCreate, "new", []string{`+ steps.filter: {"robot":"/file/filter"}`}},
+		{transloadit.TemplateDelete, "obsolete", []string{`- steps.import: {"robot":"/http/import"}`}},
+		{transloadit.TemplateUpdate, "thumbnails", []string{
+			`+ steps.optimize: {"robot":"/image/optimize"}`,
+			`~ steps.resize.width: 75 -> 100`,
+			`+ notify_url: "https://example.com"`,
+		}},
+		{transloadit.TemplateUnchanged, "unchanged", nil},
+	}
+
+	check := func(changes []transloadit.TemplateChange) {
+		t.Helper()
+		if len(changes) != len(expected) {
+			t.Fatalf("wrong changes %+v", changes)
+		}
+		for i, change := range changes {
+			if change.Action != expected[i].action || change.Name != expected[i].name || !reflect.DeepEqual(change.Diff, expected[i].diff) {
+				t.Fatalf("wrong change %+v", change)
+			}
+		}
+	}
+
+	changes, err := client.SyncTemplates(ctx, dir, transloadit.TemplateSyncOptions{Delete: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(changes)
+
+	list, err := client.ListTemplates(ctx, &transloadit.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 3 {
+		t.Fatal("dry run should not change templates")
+	}
+
+	changes, err = client.SyncTemplates(ctx, dir, transloadit.TemplateSyncOptions{Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(changes)
+	if changes[0].ID == "" {
+		t.Fatal("created template should have an ID")
+	}
+
+	updated, err := client.GetTemplate(ctx, changes[2].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content.AdditionalProperties["notify_url"] != "https://example.com" || !updated.RequireSignatureAuth {
+		t.Fatalf("wrong updated template %+v", updated)
+	}
+
+	// Once converged, all templates are unchanged.
+	changes, err = client.SyncTemplates(ctx, dir, transloadit.TemplateSyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.Action != transloadit.TemplateUnchanged {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	}
+}
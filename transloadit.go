@@ -3,10 +3,6 @@ package transloadit
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,8 +10,6 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
-	"sort"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -25,6 +19,40 @@ type Config struct {
 	AuthKey    string
 	AuthSecret string
 	Endpoint   string
+	// UploadStore persists the progress of resumable uploads and is required
+	// by Client.ResumeAssembly. If set, tus uploads started using
+	// Client.StartAssembly are recorded as well. See NewMemoryUploadStore and
+	// NewFileUploadStore.
+	UploadStore UploadStore
+	// RetryPolicy defines whether and how failed API requests are retried.
+	// Retries are disabled if left unset. See DefaultRetryPolicy.
+	RetryPolicy RetryPolicy
+	// SignatureTTL specifies how long the signatures of API requests remain
+	// valid. Defaults to one hour if left unset.
+	SignatureTTL time.Duration
+	// Clock returns the current time used for the expiry of signatures.
+	// Defaults to time.Now if left unset. If the API rejects a signature as
+	// INVALID_SIGNATURE and the Date header of the response shows that it has
+	// expired, the client corrects its clock by the difference to the API's
+	// time and retries the request once. Multipart uploads by StartAssembly are not retried, since their
+	// files cannot be read again.
+	Clock func() time.Time
+	// HTTPClient is used for all HTTP requests, allowing to configure
+	// timeouts, redirects or cookies. It is copied by NewClient and defaults
+	// to a client without timeout. Since uploads and downloads of large files
+	// may take a long time, http.Client.Timeout should be chosen generously.
+	HTTPClient *http.Client
+	// Transport replaces the transport of HTTPClient, for example to use a
+	// proxy or custom TLS settings. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Middleware wraps the transport in the provided order, so that the first
+	// middleware receives each request first.
+	Middleware []Middleware
+	// Tracer and Meter enable the instrumentation of API calls. If Tracer is
+	// set, a span is created for every call. If Meter is set, the histograms
+	// MetricRequestDuration and MetricUploadSize are recorded.
+	Tracer Tracer
+	Meter  Meter
 }
 
 // DefaultConfig is the recommended base configuration.
@@ -38,6 +66,7 @@ type Client struct {
 	config     Config
 	httpClient *http.Client
 	random     *rand.Rand
+	clock      *clock
 }
 
 // ListOptions defines criteria used when a list is being retrieved. Details
@@ -53,6 +82,11 @@ type ListOptions struct {
 	AssemblyID string     `json:"assembly_id,omitempty"`
 	FromDate   *time.Time `json:"fromdate,omitempty"`
 	ToDate     *time.Time `json:"todate,omitempty"`
+
+	// Prefetch specifies whether iterators, such as Client.Assemblies, fetch
+	// the next page concurrently while the current one is being consumed.
+	// It is not sent to the API.
+	Prefetch bool `json:"-"`
 }
 
 type authParams struct {
@@ -68,10 +102,26 @@ type authListOptions struct {
 }
 
 // RequestError represents an error returned by the Transloadit API alongside
-// additional service-specific information.
+// additional service-specific information. It can be compared with the
+// sentinel errors, such as ErrNotFound, using errors.Is.
 type RequestError struct {
 	Code    string `json:"error"`
 	Message string `json:"message"`
+	Reason  string `json:"reason"`
+
+	// AssemblyID and AssemblySSLURL are set if the error is related to an
+	// assembly, for example if it could not be created.
+	AssemblyID     string `json:"assembly_id"`
+	AssemblySSLURL string `json:"assembly_ssl_url"`
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+	// RequestID is the identifier which the API assigned to the request, if
+	// any. It should be included when reporting issues to Transloadit.
+	RequestID string `json:"-"`
+
+	retryAfter       time.Duration
+	signatureExpired bool
 }
 
 // Error return a formatted message describing the error.
@@ -79,6 +129,22 @@ func (err RequestError) Error() string {
 	return fmt.Sprintf("request failed due to %s: %s", err.Code, err.Message)
 }
 
+// Is reports whether the error matches one of the sentinel errors
+// ErrRateLimited, ErrSignatureInvalid, ErrNotFound or ErrAssemblyFailed.
+func (err RequestError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return err.Code == "RATE_LIMIT_REACHED" || err.StatusCode == http.StatusTooManyRequests
+	case ErrSignatureInvalid:
+		return err.Code == invalidSignatureCode
+	case ErrNotFound:
+		return err.StatusCode == http.StatusNotFound || strings.HasSuffix(err.Code, "_NOT_FOUND")
+	case ErrAssemblyFailed:
+		return err.AssemblyID != ""
+	}
+	return false
+}
+
 // NewClient creates a new client using the provided configuration struct.
 // It will panic if no Config.AuthKey or Config.AuthSecret are empty.
 func NewClient(config Config) Client {
@@ -92,8 +158,9 @@ func NewClient(config Config) Client {
 
 	client := Client{
 		config:     config,
-		httpClient: &http.Client{},
+		httpClient: newHTTPClient(config),
 		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:      newClock(config.Clock),
 	}
 
 	return client
@@ -102,31 +169,32 @@ func NewClient(config Config) Client {
 func (client *Client) sign(params map[string]interface{}) (string, string, error) {
 	params["auth"] = authParams{
 		Key:     client.config.AuthKey,
-		Expires: getExpireString(),
+		Expires: client.expireString(),
 	}
 	// Add a random nonce to make signatures unique and prevent error about
 	// signature reuse: https://github.com/transloadit/go-sdk/pull/35
 	params["nonce"] = client.random.Int()
 	contentToSign, err := json.Marshal(params)
 	if err != nil {
-		return "", "", fmt.Errorf("unable to create signature: %s", err)
+		return "", "", fmt.Errorf("unable to create signature: %w", err)
 	}
 
-	hash := hmac.New(sha512.New384, []byte(client.config.AuthSecret))
-	hash.Write(contentToSign)
-	signature := "sha384:" + hex.EncodeToString(hash.Sum(nil))
-
-	return string(contentToSign), signature, nil
+	return string(contentToSign), client.signature(contentToSign), nil
 }
 
 func (client *Client) doRequest(req *http.Request, result interface{}) error {
 	req.Header.Set("Transloadit-Client", "go-sdk:"+Version)
 
+	// The request has been signed just before, so the signature expires one
+	// TTL after this time.
+	signedAt := client.clock.Now()
+
 	res, err := client.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed execute http request: %s", err)
+		return fmt.Errorf("failed execute http request: %w", err)
 	}
 	defer res.Body.Close()
+	operationFromContext(req.Context()).setStatusCode(res.StatusCode)
 
 	// Limit response to 128MB
 	reader := io.LimitReader(res.Body, 128*1024*1024)
@@ -136,18 +204,33 @@ func (client *Client) doRequest(req *http.Request, result interface{}) error {
 	}
 
 	if !(res.StatusCode >= 200 && res.StatusCode < 300) {
+		retryAfter := parseRetryAfter(res.Header.Get("Retry-After"), body)
+
 		var reqErr RequestError
 		if err := json.Unmarshal(body, &reqErr); err != nil {
-			return fmt.Errorf("failed unmarshal http request: %s", err)
+			return statusError{
+				statusCode: res.StatusCode,
+				retryAfter: retryAfter,
+				err:        fmt.Errorf("failed unmarshal http request: %w", err),
+			}
 		}
 
+		reqErr.StatusCode = res.StatusCode
+		reqErr.RequestID = requestID(res.Header)
+		reqErr.retryAfter = retryAfter
+
+		// Correct the clock if the signature has expired according to the
+		// API, so that subsequent signatures are valid.
+		if reqErr.Code == invalidSignatureCode {
+			reqErr.signatureExpired = client.clock.expired(res.Header, signedAt, client.signatureTTL())
+		}
 		return reqErr
 	}
 
 	if result != nil {
 		if err := json.Unmarshal(body, result); err != nil {
 			fmt.Println(string(body))
-			return fmt.Errorf("failed unmarshal http request: %s", err)
+			return fmt.Errorf("failed unmarshal http request: %w", err)
 		}
 	}
 
@@ -166,140 +249,93 @@ func (client *Client) request(ctx context.Context, method string, path string, c
 		content = make(map[string]interface{})
 	}
 
-	// Create signature
-	params, signature, err := client.sign(content)
-	if err != nil {
-		return fmt.Errorf("request: %s", err)
-	}
+	ctx, op := client.startOperation(ctx, method, uri)
+	err := client.doRequestWithRetry(ctx, func() (*http.Request, error) {
+		// Create signature
+		params, signature, err := client.sign(content)
+		if err != nil {
+			return nil, fmt.Errorf("request: %w", err)
+		}
 
-	v := url.Values{}
-	v.Set("params", params)
-	v.Set("signature", signature)
+		v := url.Values{}
+		v.Set("params", params)
+		v.Set("signature", signature)
 
-	var body io.Reader
-	if method == "GET" {
-		uri += "?" + v.Encode()
-	} else {
-		body = strings.NewReader(v.Encode())
-	}
-	req, err := http.NewRequest(method, uri, body)
-	if err != nil {
-		return fmt.Errorf("request: %s", err)
-	}
-	req = req.WithContext(ctx)
+		var body io.Reader
+		reqURI := uri
+		if method == "GET" {
+			reqURI += "?" + v.Encode()
+		} else {
+			body = strings.NewReader(v.Encode())
+		}
+		req, err := http.NewRequest(method, reqURI, body)
+		if err != nil {
+			return nil, fmt.Errorf("request: %w", err)
+		}
+		req = req.WithContext(ctx)
 
-	if method != "GET" {
-		// Add content type header
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	}
+		if method != "GET" {
+			// Add content type header
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		return req, nil
+	}, result)
 
-	return client.doRequest(req, result)
+	if info, ok := result.(*AssemblyInfo); ok && err == nil {
+		op.setAssemblyID(info.AssemblyID)
+	}
+	op.end(err)
+	return err
 }
 
 func (client *Client) listRequest(ctx context.Context, path string, listOptions *ListOptions, result interface{}) error {
 	uri := client.config.Endpoint + "/" + path
 
-	options := authListOptions{
-		ListOptions: listOptions,
-		Auth: authParams{
-			Key:     client.config.AuthKey,
-			Expires: getExpireString(),
-		},
-	}
-
-	b, err := json.Marshal(options)
-	if err != nil {
-		return fmt.Errorf("unable to create signature: %s", err)
-	}
-
-	hash := hmac.New(sha512.New384, []byte(client.config.AuthSecret))
-	hash.Write(b)
+	ctx, op := client.startOperation(ctx, "GET", uri)
+	err := client.doRequestWithRetry(ctx, func() (*http.Request, error) {
+		options := authListOptions{
+			ListOptions: listOptions,
+			Auth: authParams{
+				Key:     client.config.AuthKey,
+				Expires: client.expireString(),
+			},
+		}
 
-	params := string(b)
-	signature := "sha384:" + hex.EncodeToString(hash.Sum(nil))
+		b, err := json.Marshal(options)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signature: %w", err)
+		}
 
-	v := url.Values{}
-	v.Set("params", params)
-	v.Set("signature", signature)
+		params := string(b)
+		signature := client.signature(b)
 
-	uri += "?" + v.Encode()
+		v := url.Values{}
+		v.Set("params", params)
+		v.Set("signature", signature)
 
-	req, err := http.NewRequest("GET", uri, nil)
-	if err != nil {
-		return fmt.Errorf("request: %s", err)
-	}
-	req = req.WithContext(ctx)
+		req, err := http.NewRequest("GET", uri+"?"+v.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("request: %w", err)
+		}
+		req = req.WithContext(ctx)
 
-	return client.doRequest(req, result)
-}
+		return req, nil
+	}, result)
 
-func getExpireString() string {
-	// Expires in 1 hour
-	expires := time.Now().UTC().Add(time.Hour)
-	expiresStr := fmt.Sprintf("%04d/%02d/%02d %02d:%02d:%02d+00:00", expires.Year(), expires.Month(), expires.Day(), expires.Hour(), expires.Minute(), expires.Second())
-	return string(expiresStr)
+	op.end(err)
+	return err
 }
 
-// SignedSmartCDNUrlOptions contains options for creating a signed Smart CDN URL
-type SignedSmartCDNUrlOptions struct {
-	// Workspace slug
-	Workspace string
-	// Template slug or template ID
-	Template string
-	// Input value that is provided as `${fields.input}` in the template
-	Input string
-	// Additional parameters for the URL query string. Can be nil.
-	URLParams url.Values
-	// Expiration timestamp of the signature. Defaults to 1 hour from now if left unset.
-	ExpiresAt time.Time
+// expireString returns the expiry for signatures of API requests.
+func (client *Client) expireString() string {
+	return formatExpires(client.clock.Now().Add(client.signatureTTL()))
 }
 
-// CreateSignedSmartCDNUrl constructs a signed Smart CDN URL.
-// See https://transloadit.com/docs/topics/signature-authentication/#smart-cdn
-func (client *Client) CreateSignedSmartCDNUrl(opts SignedSmartCDNUrlOptions) string {
-	workspaceSlug := url.PathEscape(opts.Workspace)
-	templateSlug := url.PathEscape(opts.Template)
-	inputField := url.PathEscape(opts.Input)
-
-	var expiresAt int64
-	if !opts.ExpiresAt.IsZero() {
-		expiresAt = opts.ExpiresAt.Unix() * 1000
-	} else {
-		expiresAt = time.Now().Add(time.Hour).Unix() * 1000 // 1 hour
+// signatureTTL returns how long the signatures of API requests remain valid.
+func (client *Client) signatureTTL() time.Duration {
+	if client.config.SignatureTTL <= 0 {
+		return time.Hour
 	}
-
-	queryParams := make(url.Values, len(opts.URLParams)+2)
-	for key, values := range opts.URLParams {
-		queryParams[key] = values
-	}
-
-	queryParams.Set("auth_key", client.config.AuthKey)
-	queryParams.Set("exp", strconv.FormatInt(expiresAt, 10))
-
-	// Build query string with sorted keys
-	queryParamsKeys := make([]string, 0, len(queryParams))
-	for k := range queryParams {
-		queryParamsKeys = append(queryParamsKeys, k)
-	}
-	sort.Strings(queryParamsKeys)
-
-	var queryParts []string
-	for _, k := range queryParamsKeys {
-		for _, v := range queryParams[k] {
-			queryParts = append(queryParts, url.QueryEscape(k)+"="+url.QueryEscape(v))
-		}
-	}
-	queryString := strings.Join(queryParts, "&")
-
-	stringToSign := fmt.Sprintf("%s/%s/%s?%s", workspaceSlug, templateSlug, inputField, queryString)
-
-	// Smart CDN signatures intentionally remain SHA-256; API request signatures use SHA-384.
-	hash := hmac.New(sha256.New, []byte(client.config.AuthSecret))
-	hash.Write([]byte(stringToSign))
-	signature := url.QueryEscape("sha256:" + hex.EncodeToString(hash.Sum(nil)))
-
-	signedURL := fmt.Sprintf("https://%s.tlcdn.com/%s/%s?%s&sig=%s",
-		workspaceSlug, templateSlug, inputField, queryString, signature)
-
-	return signedURL
+	return client.config.SignatureTTL
 }
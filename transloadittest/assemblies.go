@@ -0,0 +1,233 @@
+package transloadittest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+// status returns the assembly status for the current state.
+func (a *assembly) status() transloadit.AssemblyInfo {
+	info := a.info
+	state := a.states[a.current]
+	info.Ok = state.Ok
+	info.Error = state.Error
+	info.Message = state.Message
+	return info
+}
+
+// advance moves the assembly to its next state, if there is one.
+func (a *assembly) advance() {
+	if a.current < len(a.states)-1 {
+		a.current++
+	}
+}
+
+func (server *Server) newAssembly(params map[string]interface{}, states []AssemblyState) *assembly {
+	id := server.newID()
+	assemblyURL := server.URL + "/assemblies/" + id
+
+	a := &assembly{
+		info: transloadit.AssemblyInfo{
+			AssemblyID:     id,
+			AssemblyURL:    assemblyURL,
+			AssemblySSLURL: assemblyURL,
+			Created:        time.Now().UTC().Format(time.RFC3339),
+			Uploads:        []*transloadit.FileInfo{},
+			Results:        map[string][]*transloadit.FileInfo{},
+		},
+		states: append([]AssemblyState(nil), states...),
+	}
+	if len(a.states) == 0 {
+		a.states = []AssemblyState{StateCompleted}
+	}
+
+	if b, err := json.Marshal(params); err == nil {
+		a.info.Params = string(b)
+	}
+	if notifyURL, ok := params["notify_url"].(string); ok {
+		a.info.NotifyURL = notifyURL
+	}
+	if fields, ok := params["fields"].(map[string]interface{}); ok {
+		a.info.Fields = fields
+	}
+
+	server.assemblies[id] = a
+	server.order = append(server.order, id)
+	return a
+}
+
+func (server *Server) createAssembly(r *http.Request, params map[string]interface{}) (interface{}, *apiError) {
+	if templateID, ok := params["template_id"].(string); ok {
+		if _, ok := server.templates[templateID]; !ok {
+			return nil, &apiError{http.StatusNotFound, "TEMPLATE_NOT_FOUND", "The template " + templateID + " does not exist."}
+		}
+	}
+
+	a := server.newAssembly(params, server.transitions)
+
+	if r.MultipartForm != nil {
+		for field, headers := range r.MultipartForm.File {
+			for _, header := range headers {
+				upload, err := newUpload(field, header)
+				if err != nil {
+					return nil, &apiError{http.StatusBadRequest, "UPLOAD_FAILED", err.Error()}
+				}
+				upload.ID = server.newID()
+				a.info.Uploads = append(a.info.Uploads, upload)
+				a.info.BytesReceived += upload.Size
+			}
+		}
+		a.info.BytesExpected = transloadit.Integer(a.info.BytesReceived)
+	}
+
+	return a.status(), nil
+}
+
+func newUpload(field string, header *multipart.FileHeader) (*transloadit.FileInfo, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(header.Filename)
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+
+	return &transloadit.FileInfo{
+		Name:     name,
+		Basename: strings.TrimSuffix(name, path.Ext(name)),
+		Ext:      ext,
+		Size:     int(size),
+		Mime:     header.Header.Get("Content-Type"),
+		Field:    field,
+		Md5Hash:  hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func (server *Server) getAssembly(id string) (interface{}, *apiError) {
+	a, ok := server.assemblies[id]
+	if !ok {
+		return nil, assemblyNotFound(id)
+	}
+
+	a.advance()
+	return a.status(), nil
+}
+
+func (server *Server) cancelAssembly(id string) (interface{}, *apiError) {
+	a, ok := server.assemblies[id]
+	if !ok {
+		return nil, assemblyNotFound(id)
+	}
+
+	a.states = append(a.states[:a.current+1], StateCanceled)
+	a.advance()
+	return a.status(), nil
+}
+
+func (server *Server) replayAssembly(id string, params map[string]interface{}) (interface{}, *apiError) {
+	parent, ok := server.assemblies[id]
+	if !ok {
+		return nil, assemblyNotFound(id)
+	}
+
+	states := append([]AssemblyState{StateReplaying}, server.transitions...)
+	a := server.newAssembly(params, states)
+	a.info.ParentID = id
+	a.info.Uploads = parent.info.Uploads
+	if a.info.NotifyURL == "" {
+		a.info.NotifyURL = parent.info.NotifyURL
+	}
+
+	return a.status(), nil
+}
+
+func (server *Server) listAssemblies(params map[string]interface{}) (interface{}, *apiError) {
+	items := make([]*transloadit.AssemblyListItem, 0, len(server.order))
+	for _, id := range server.order {
+		a := server.assemblies[id]
+		status := a.status()
+		created, _ := time.Parse(time.RFC3339, status.Created)
+		items = append(items, &transloadit.AssemblyListItem{
+			Ok:         status.Ok,
+			Error:      status.Error,
+			AssemblyID: id,
+			NotifyURL:  status.NotifyURL,
+			Created:    created,
+		})
+	}
+
+	start, end := page(params, len(items))
+	return transloadit.AssemblyList{
+		Assemblies: items[start:end],
+		Count:      len(items),
+	}, nil
+}
+
+func (server *Server) replayNotification(id string, params map[string]interface{}) (interface{}, *apiError) {
+	a, ok := server.assemblies[id]
+	if !ok {
+		return nil, assemblyNotFound(id)
+	}
+
+	notifyURL, _ := params["notify_url"].(string)
+	if notifyURL == "" {
+		notifyURL = a.info.NotifyURL
+	}
+	if notifyURL == "" {
+		return nil, &apiError{http.StatusBadRequest, "NO_NOTIFY_URL", "The assembly has no notify URL."}
+	}
+
+	server.replays = append(server.replays, NotificationReplay{
+		AssemblyID: id,
+		NotifyURL:  notifyURL,
+	})
+
+	return map[string]string{
+		"ok":      "ASSEMBLY_NOTIFICATION_REPLAYED",
+		"success": "true",
+	}, nil
+}
+
+// page returns the bounds of the page selected by the page and pagesize
+// params for a list with the provided length.
+func page(params map[string]interface{}, length int) (int, int) {
+	pageNumber, _ := params["page"].(float64)
+	pageSize, _ := params["pagesize"].(float64)
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+
+	start := int(pageNumber-1) * int(pageSize)
+	if start > length {
+		start = length
+	}
+	end := start + int(pageSize)
+	if end > length {
+		end = length
+	}
+
+	return start, end
+}
+
+func assemblyNotFound(id string) *apiError {
+	return &apiError{http.StatusNotFound, "ASSEMBLY_NOT_FOUND", "The assembly " + id + " could not be found."}
+}
@@ -0,0 +1,482 @@
+// Package transloadittest provides an in-memory fake of the Transloadit API
+// for integration tests. The server verifies request signatures just like
+// the real API, keeps assemblies, templates and template credentials in
+// memory, records the received requests and allows tests to script the states
+// an assembly goes through and to inject errors:
+//
+//	server := transloadittest.NewServer("key", "secret")
+//	defer server.Close()
+//
+//	client := transloadit.NewClient(server.Config())
+//	info, err := client.StartAssembly(ctx, assembly)
+package transloadittest
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+// AssemblyState describes the status reported for an assembly at one point
+// in time. If Error is set, the assembly is reported as failed.
+type AssemblyState struct {
+	Ok      string
+	Error   string
+	Message string
+}
+
+var (
+	// StateUploading is reported while an assembly is receiving uploads.
+	StateUploading = AssemblyState{Ok: "ASSEMBLY_UPLOADING"}
+	// StateExecuting is reported while an assembly's steps are executed.
+	StateExecuting = AssemblyState{Ok: "ASSEMBLY_EXECUTING"}
+	// StateCompleted is reported once an assembly has finished successfully.
+	StateCompleted = AssemblyState{Ok: "ASSEMBLY_COMPLETED"}
+	// StateReplaying is reported by a newly replayed assembly.
+	StateReplaying = AssemblyState{Ok: "ASSEMBLY_REPLAYING"}
+	// StateCanceled is reported once an assembly has been canceled.
+	StateCanceled = AssemblyState{Ok: "ASSEMBLY_CANCELED"}
+)
+
+// Fault describes an error response which the server sends instead of
+// handling a matching request.
+type Fault struct {
+	// Method matches the request's HTTP method. An empty value matches all
+	// methods.
+	Method string
+	// Path matches the request's URL path, for example "/assemblies". A
+	// trailing "*" matches all paths with the preceding prefix. An empty
+	// value matches all paths.
+	Path string
+	// StatusCode is the response's HTTP status code. Defaults to 500.
+	StatusCode int
+	// Code and Message are sent as `error` and `message` in the response body.
+	Code    string
+	Message string
+	// Body replaces the response body if set, for example to respond with an
+	// HTML error page or to include additional properties.
+	Body string
+	// Header contains additional response headers, such as Retry-After.
+	Header http.Header
+	// Times is the number of matching requests which fail. Zero means that
+	// all matching requests fail until the fault is cleared.
+	Times int
+}
+
+// Request records a request received by the server.
+type Request struct {
+	Method string
+	// Path is the request's URL path, for example "/assemblies".
+	Path string
+	// Params contains the decoded params field, or nil if the request has
+	// none.
+	Params map[string]interface{}
+}
+
+// NotificationReplay records a call to the notification replay endpoint.
+type NotificationReplay struct {
+	AssemblyID string
+	NotifyURL  string
+}
+
+// Server is a fake Transloadit API server. All methods are safe for
+// concurrent use.
+type Server struct {
+	// URL is the server's base URL, suitable for transloadit.Config.Endpoint.
+	URL string
+
+	authKey    string
+	authSecret string
+	server     *httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	transitions []AssemblyState
+	faults      []*Fault
+	assemblies  map[string]*assembly
+	order       []string
+	templates   map[string]*template
+	credentials map[string]*credential
+	replays     []NotificationReplay
+	requests    []Request
+}
+
+type assembly struct {
+	info    transloadit.AssemblyInfo
+	states  []AssemblyState
+	current int
+}
+
+type template struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	Content              map[string]interface{} `json:"content"`
+	RequireSignatureAuth int                    `json:"require_signature_auth"`
+}
+
+type credential struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Type     string                 `json:"type"`
+	Content  map[string]interface{} `json:"content"`
+	Created  string                 `json:"created,omitempty"`
+	Modified string                 `json:"modified,omitempty"`
+}
+
+// NewServer starts a new server which accepts requests signed with the
+// provided credentials. It must be closed using Server.Close.
+func NewServer(authKey, authSecret string) *Server {
+	server := &Server{
+		authKey:     authKey,
+		authSecret:  authSecret,
+		transitions: []AssemblyState{StateExecuting, StateCompleted},
+		assemblies:  make(map[string]*assembly),
+		templates:   make(map[string]*template),
+		credentials: make(map[string]*credential),
+	}
+	server.server = httptest.NewServer(http.HandlerFunc(server.handle))
+	server.URL = server.server.URL
+
+	return server
+}
+
+// Close shuts down the server.
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// Config returns a client configuration for talking to the server.
+func (server *Server) Config() transloadit.Config {
+	config := transloadit.DefaultConfig
+	config.AuthKey = server.authKey
+	config.AuthSecret = server.authSecret
+	config.Endpoint = server.URL
+	return config
+}
+
+// SetTransitions defines the states which newly created assemblies go
+// through. The first state is returned when the assembly is created and every
+// following status request advances the assembly to the next state until the
+// last one is reached. By default, assemblies are created as executing and
+// are completed on the first status request.
+func (server *Server) SetTransitions(states ...AssemblyState) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.transitions = append([]AssemblyState(nil), states...)
+}
+
+// ScriptAssembly replaces the remaining states of an existing assembly. The
+// next status request reports the first of the provided states.
+func (server *Server) ScriptAssembly(assemblyID string, states ...AssemblyState) error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	a, ok := server.assemblies[assemblyID]
+	if !ok {
+		return fmt.Errorf("transloadittest: unknown assembly %s", assemblyID)
+	}
+
+	a.states = append(a.states[:a.current+1], states...)
+	return nil
+}
+
+// AddResult adds a result file for the provided step to an existing
+// assembly. Missing IDs and URLs are generated.
+func (server *Server) AddResult(assemblyID, step string, file transloadit.FileInfo) error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	a, ok := server.assemblies[assemblyID]
+	if !ok {
+		return fmt.Errorf("transloadittest: unknown assembly %s", assemblyID)
+	}
+
+	if file.ID == "" {
+		file.ID = server.newID()
+	}
+	if file.SSLURL == "" {
+		file.SSLURL = server.URL + "/results/" + assemblyID + "/" + file.ID
+	}
+	if file.URL == "" {
+		file.URL = file.SSLURL
+	}
+
+	if a.info.Results == nil {
+		a.info.Results = make(map[string][]*transloadit.FileInfo)
+	}
+	a.info.Results[step] = append(a.info.Results[step], &file)
+	return nil
+}
+
+// Assembly returns the current status of the assembly without advancing its
+// state.
+func (server *Server) Assembly(assemblyID string) (transloadit.AssemblyInfo, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	a, ok := server.assemblies[assemblyID]
+	if !ok {
+		return transloadit.AssemblyInfo{}, false
+	}
+
+	return a.status(), true
+}
+
+// InjectFault adds a fault to the server. Faults are evaluated in the order
+// they were added.
+func (server *Server) InjectFault(fault Fault) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if fault.StatusCode == 0 {
+		fault.StatusCode = http.StatusInternalServerError
+	}
+	server.faults = append(server.faults, &fault)
+}
+
+// ClearFaults removes all injected faults.
+func (server *Server) ClearFaults() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.faults = nil
+}
+
+// NotificationReplays returns all notification replays requested so far.
+func (server *Server) NotificationReplays() []NotificationReplay {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return append([]NotificationReplay(nil), server.replays...)
+}
+
+// Requests returns all requests received so far, including those which
+// failed due to an injected fault or an invalid signature.
+func (server *Server) Requests() []Request {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return append([]Request(nil), server.requests...)
+}
+
+// apiError is an error response sent by the API.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	form, err := parseForm(r)
+
+	request := Request{Method: r.Method, Path: r.URL.Path}
+	if err == nil {
+		json.Unmarshal([]byte(form.Get("params")), &request.Params)
+	}
+	server.requests = append(server.requests, request)
+
+	if fault := server.matchFault(r); fault != nil {
+		for key, values := range fault.Header {
+			w.Header()[key] = values
+		}
+		if fault.Body != "" {
+			w.WriteHeader(fault.StatusCode)
+			io.WriteString(w, fault.Body)
+			return
+		}
+		writeError(w, apiError{fault.StatusCode, fault.Code, fault.Message})
+		return
+	}
+
+	if err != nil {
+		writeError(w, apiError{http.StatusBadRequest, "INVALID_FORM_DATA", err.Error()})
+		return
+	}
+
+	params, apiErr := server.verify(form)
+	if apiErr != nil {
+		writeError(w, *apiErr)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var result interface{}
+	switch {
+	case r.Method == "POST" && len(segments) == 1 && segments[0] == "assemblies":
+		result, apiErr = server.createAssembly(r, params)
+	case r.Method == "GET" && len(segments) == 1 && segments[0] == "assemblies":
+		result, apiErr = server.listAssemblies(params)
+	case r.Method == "GET" && len(segments) == 2 && segments[0] == "assemblies":
+		result, apiErr = server.getAssembly(segments[1])
+	case r.Method == "DELETE" && len(segments) == 2 && segments[0] == "assemblies":
+		result, apiErr = server.cancelAssembly(segments[1])
+	case r.Method == "POST" && len(segments) == 3 && segments[0] == "assemblies" && segments[2] == "replay":
+		result, apiErr = server.replayAssembly(segments[1], params)
+	case r.Method == "POST" && len(segments) == 3 && segments[0] == "assembly_notifications" && segments[2] == "replay":
+		result, apiErr = server.replayNotification(segments[1], params)
+	case len(segments) >= 1 && segments[0] == "templates":
+		result, apiErr = server.handleTemplates(r.Method, segments[1:], params)
+	case len(segments) >= 1 && segments[0] == "template_credentials":
+		result, apiErr = server.handleCredentials(r.Method, segments[1:], params)
+	default:
+		apiErr = &apiError{http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path)}
+	}
+
+	if apiErr != nil {
+		writeError(w, *apiErr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (server *Server) matchFault(r *http.Request) *Fault {
+	for i, fault := range server.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if strings.HasSuffix(fault.Path, "*") {
+			if !strings.HasPrefix(r.URL.Path, strings.TrimSuffix(fault.Path, "*")) {
+				continue
+			}
+		} else if fault.Path != "" && fault.Path != r.URL.Path {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				server.faults = append(server.faults[:i:i], server.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+
+	return nil
+}
+
+// parseForm returns the form values from the query string and the body.
+// http.Request.ParseForm is not sufficient since it ignores the body of
+// DELETE requests.
+func parseForm(r *http.Request) (url.Values, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 * 1024 * 1024); err != nil {
+			return nil, err
+		}
+		return r.Form, nil
+	}
+
+	form := r.URL.Query()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > 0 {
+		bodyForm, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range bodyForm {
+			form[key] = append(form[key], values...)
+		}
+	}
+
+	return form, nil
+}
+
+// verify checks the signature of the params and returns them decoded.
+func (server *Server) verify(form url.Values) (map[string]interface{}, *apiError) {
+	rawParams := form.Get("params")
+	if rawParams == "" {
+		return nil, &apiError{http.StatusBadRequest, "NO_PARAMS_FIELD", "No params field provided."}
+	}
+
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(rawParams), &params); err != nil {
+		return nil, &apiError{http.StatusBadRequest, "INVALID_PARAMS_FIELD", err.Error()}
+	}
+
+	auth, _ := params["auth"].(map[string]interface{})
+	if auth == nil || auth["key"] != server.authKey {
+		return nil, &apiError{http.StatusBadRequest, "GET_ACCOUNT_UNKNOWN_AUTH_KEY", "Unknown auth key."}
+	}
+
+	expires, _ := auth["expires"].(string)
+	expiresAt, err := time.Parse("2006/01/02 15:04:05-07:00", expires)
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, "INVALID_AUTH_EXPIRES_PARAMETER", "Invalid auth.expires parameter."}
+	}
+	if expiresAt.Before(time.Now()) {
+		return nil, &apiError{http.StatusUnauthorized, "INVALID_SIGNATURE", "The signature has expired."}
+	}
+
+	signature := form.Get("signature")
+	algorithm := "sha1"
+	if i := strings.IndexByte(signature, ':'); i != -1 {
+		algorithm = signature[:i]
+		signature = signature[i+1:]
+	}
+
+	var newHash func() hash.Hash
+	switch algorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if newHash == nil || err != nil {
+		return nil, &apiError{http.StatusUnauthorized, "INVALID_SIGNATURE", "The signature is malformed."}
+	}
+
+	mac := hmac.New(newHash, []byte(server.authSecret))
+	mac.Write([]byte(rawParams))
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return nil, &apiError{http.StatusUnauthorized, "INVALID_SIGNATURE", "The signature does not match the params."}
+	}
+
+	return params, nil
+}
+
+func (server *Server) newID() string {
+	server.nextID++
+	return fmt.Sprintf("%032x", server.nextID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err apiError) {
+	writeJSON(w, err.status, map[string]string{
+		"error":   err.code,
+		"message": err.message,
+	})
+}
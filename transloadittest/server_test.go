@@ -0,0 +1,342 @@
+package transloadittest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+var ctx = context.Background()
+
+func TestServer_AssemblyLifecycle(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+	server.SetTransitions(transloadittest.StateUploading, transloadittest.StateExecuting, transloadittest.StateCompleted)
+
+	client := transloadit.NewClient(server.Config())
+
+	assembly := transloadit.NewAssembly()
+	assembly.AddReader("image", "lol_cat.jpg", ioutil.NopCloser(strings.NewReader("meow")))
+	assembly.AddStep("resize", map[string]interface{}{
+		"robot": "/image/resize",
+	})
+	assembly.NotifyURL = "https://example.com/notify"
+	assembly.Fields["foo"] = "bar"
+
+	info, err := client.StartAssembly(ctx, assembly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Ok != "ASSEMBLY_UPLOADING" {
+		t.Fatalf("wrong status %q", info.Ok)
+	}
+	if info.NotifyURL != "https://example.com/notify" || info.Fields["foo"] != "bar" {
+		t.Fatal("assembly instructions were not stored")
+	}
+	if len(info.Uploads) != 1 || info.Uploads[0].Name != "lol_cat.jpg" || info.Uploads[0].Size != 4 {
+		t.Fatalf("wrong uploads %+v", info.Uploads)
+	}
+
+	if err := server.AddResult(info.AssemblyID, "resize", transloadit.FileInfo{Name: "lol_cat.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"ASSEMBLY_EXECUTING", "ASSEMBLY_COMPLETED", "ASSEMBLY_COMPLETED"}
+	for _, status := range expected {
+		info, err = client.GetAssembly(ctx, info.AssemblySSLURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Ok != status {
+			t.Fatalf("expected status %s, got %s", status, info.Ok)
+		}
+	}
+
+	if len(info.Results["resize"]) != 1 || info.Results["resize"][0].SSLURL == "" {
+		t.Fatalf("wrong results %+v", info.Results)
+	}
+
+	replay := transloadit.NewAssemblyReplay(info.AssemblySSLURL)
+	replayInfo, err := client.StartAssemblyReplay(ctx, replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayInfo.Ok != "ASSEMBLY_REPLAYING" || replayInfo.ParentID != info.AssemblyID {
+		t.Fatalf("wrong replay status %+v", replayInfo)
+	}
+
+	if err := client.ReplayNotification(ctx, info.AssemblyID, ""); err != nil {
+		t.Fatal(err)
+	}
+	replays := server.NotificationReplays()
+	if len(replays) != 1 || replays[0].NotifyURL != "https://example.com/notify" {
+		t.Fatalf("wrong notification replays %+v", replays)
+	}
+
+	list, err := client.ListAssemblies(ctx, &transloadit.ListOptions{PageSize: 1, Page: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 2 || len(list.Assemblies) != 1 || list.Assemblies[0].AssemblyID != replayInfo.AssemblyID {
+		t.Fatalf("wrong assembly list %+v", list)
+	}
+}
+
+func TestServer_ScriptAssembly(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+	server.SetTransitions(transloadittest.StateExecuting)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = server.ScriptAssembly(info.AssemblyID, transloadittest.AssemblyState{
+		Error:   "INVALID_FILE_META_DATA",
+		Message: "broken file",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = client.WaitForAssembly(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Error != "INVALID_FILE_META_DATA" {
+		t.Fatalf("wrong error %q", info.Error)
+	}
+
+	info, err = client.CancelAssembly(ctx, info.AssemblySSLURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Ok != "ASSEMBLY_CANCELED" {
+		t.Fatalf("wrong status %q", info.Ok)
+	}
+
+	if err := server.ScriptAssembly("unknown"); err == nil {
+		t.Fatal("expected an error for unknown assemblies")
+	}
+}
+
+func TestServer_Templates(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+
+	template := transloadit.NewTemplate()
+	template.Name = "resize"
+	template.RequireSignatureAuth = true
+	template.AddStep("resize", map[string]interface{}{"robot": "/image/resize"})
+
+	id, err := client.CreateTemplate(ctx, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.Name = "renamed"
+	if err := client.UpdateTemplate(ctx, id, template); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := client.GetTemplate(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Name != "renamed" || !fetched.RequireSignatureAuth || fetched.Content.Steps["resize"] == nil {
+		t.Fatalf("wrong template %+v", fetched)
+	}
+
+	assembly := transloadit.NewAssembly()
+	assembly.TemplateID = id
+	if _, err := client.StartAssembly(ctx, assembly); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := client.ListTemplates(ctx, &transloadit.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 1 || list.Templates[0].ID != id {
+		t.Fatalf("wrong template list %+v", list)
+	}
+
+	if err := client.DeleteTemplate(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetTemplate(ctx, id)
+	if reqErr, ok := err.(transloadit.RequestError); !ok || reqErr.Code != "TEMPLATE_NOT_FOUND" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestServer_TemplateCredentials(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := transloadit.NewClient(server.Config())
+
+	credential := transloadit.NewTemplateCredential()
+	credential.Name = "my-s3"
+	credential.Type = "s3"
+	credential.Content["bucket"] = "foo"
+
+	id, err := client.CreateTemplateCredential(ctx, credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential.Content["bucket"] = "bar"
+	if err := client.UpdateTemplateCredential(ctx, id, credential); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := client.GetTemplateCredential(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Name != "my-s3" || fetched.Content["bucket"] != "bar" {
+		t.Fatalf("wrong credential %+v", fetched)
+	}
+
+	list, err := client.ListTemplateCredential(ctx, &transloadit.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.TemplateCredential) != 1 {
+		t.Fatalf("wrong credential list %+v", list)
+	}
+
+	if err := client.DeleteTemplateCredential(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetTemplateCredential(ctx, id); err == nil {
+		t.Fatal("expected an error for deleted credentials")
+	}
+}
+
+func TestServer_Signature(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	config := server.Config()
+	config.AuthSecret = "wrong"
+	client := transloadit.NewClient(config)
+
+	_, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if reqErr, ok := err.(transloadit.RequestError); !ok || reqErr.Code != "INVALID_SIGNATURE" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	config = server.Config()
+	config.AuthKey = "wrong"
+	client = transloadit.NewClient(config)
+
+	_, err = client.ListTemplates(ctx, &transloadit.ListOptions{})
+	if reqErr, ok := err.(transloadit.RequestError); !ok || reqErr.Code != "GET_ACCOUNT_UNKNOWN_AUTH_KEY" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	t.Parallel()
+
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.InjectFault(transloadittest.Fault{
+		Method:     "GET",
+		Path:       "/assemblies/*",
+		StatusCode: http.StatusTooManyRequests,
+		Code:       "RATE_LIMIT_REACHED",
+		Header:     http.Header{"Retry-After": {"0"}},
+		Times:      2,
+	})
+
+	config := server.Config()
+	config.RetryPolicy = transloadit.RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      time.Millisecond,
+		RetryableCodes: []string{"RATE_LIMIT_REACHED"},
+	}
+	client := transloadit.NewClient(config)
+
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both faults are consumed by the retries.
+	if _, err := client.GetAssembly(ctx, info.AssemblySSLURL); err != nil {
+		t.Fatal(err)
+	}
+
+	server.InjectFault(transloadittest.Fault{
+		Path: "/templates",
+		Code: "INTERNAL_ERROR",
+	})
+	for i := 0; i < 2; i++ {
+		_, err = client.ListTemplates(ctx, &transloadit.ListOptions{})
+		if reqErr, ok := err.(transloadit.RequestError); !ok || reqErr.Code != "INTERNAL_ERROR" {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	server.ClearFaults()
+	if _, err := client.ListTemplates(ctx, &transloadit.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	server.InjectFault(transloadittest.Fault{
+		Path:       "/templates/*",
+		StatusCode: http.StatusBadGateway,
+		Body:       "<html>Bad Gateway</html>",
+		Times:      1,
+	})
+	config.RetryPolicy = transloadit.RetryPolicy{}
+	client = transloadit.NewClient(config)
+	_, err = client.GetTemplate(ctx, "foo")
+	if err == nil || !strings.Contains(err.Error(), "failed unmarshal") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Failed attempts are recorded as well.
+	var paths []string
+	for _, request := range server.Requests() {
+		if request.Params["auth"] == nil {
+			t.Errorf("request to %s has no params", request.Path)
+		}
+		paths = append(paths, request.Method+" "+request.Path)
+	}
+	expected := []string{"POST /assemblies", "GET /assemblies/" + info.AssemblyID, "GET /assemblies/" + info.AssemblyID, "GET /assemblies/" + info.AssemblyID}
+	for i := 0; i < 7; i++ {
+		expected = append(expected, "GET /templates")
+	}
+	expected = append(expected, "GET /templates/foo")
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("wrong requests %v", paths)
+	}
+}
@@ -0,0 +1,152 @@
+package transloadittest
+
+import (
+	"context"
+	"sync"
+
+	transloadit "github.com/transloadit/go-sdk"
+)
+
+// Recorder is an in-memory exporter implementing transloadit.Tracer and
+// transloadit.Meter, which allows tests to inspect the spans and measurements
+// of a client:
+//
+//	recorder := transloadittest.NewRecorder()
+//	config := server.Config()
+//	config.Tracer = recorder
+//	config.Meter = recorder
+//
+// All methods are safe for concurrent use.
+type Recorder struct {
+	mu           sync.Mutex
+	spans        []*recordedSpan
+	measurements []Measurement
+}
+
+// RecordedSpan is a span started using Recorder.Start.
+type RecordedSpan struct {
+	Name string
+	// Parent is the name of the span in whose context this span was started,
+	// if it was started by the same recorder.
+	Parent     string
+	Attributes map[string]interface{}
+	Errors     []error
+	Ended      bool
+}
+
+// Measurement is a value recorded using Recorder.RecordHistogram.
+type Measurement struct {
+	Name       string
+	Value      float64
+	Attributes map[string]interface{}
+}
+
+type recordedSpan struct {
+	recorder *Recorder
+	span     RecordedSpan
+}
+
+type spanKey struct{}
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start implements transloadit.Tracer.
+func (recorder *Recorder) Start(ctx context.Context, name string, attributes ...transloadit.Attribute) (context.Context, transloadit.Span) {
+	span := &recordedSpan{
+		recorder: recorder,
+		span: RecordedSpan{
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok && parent.recorder == recorder {
+		span.span.Parent = parent.span.Name
+	}
+
+	recorder.mu.Lock()
+	recorder.spans = append(recorder.spans, span)
+	recorder.mu.Unlock()
+
+	span.SetAttributes(attributes...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// RecordHistogram implements transloadit.Meter.
+func (recorder *Recorder) RecordHistogram(ctx context.Context, name string, value float64, attributes ...transloadit.Attribute) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.measurements = append(recorder.measurements, Measurement{
+		Name:       name,
+		Value:      value,
+		Attributes: attributeMap(attributes),
+	})
+}
+
+// Spans returns all spans in the order they were started.
+func (recorder *Recorder) Spans() []RecordedSpan {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(recorder.spans))
+	for i, span := range recorder.spans {
+		spans[i] = span.span
+		spans[i].Attributes = make(map[string]interface{}, len(span.span.Attributes))
+		for key, value := range span.span.Attributes {
+			spans[i].Attributes[key] = value
+		}
+		spans[i].Errors = append([]error(nil), span.span.Errors...)
+	}
+	return spans
+}
+
+// Measurements returns all measurements in the order they were recorded.
+func (recorder *Recorder) Measurements() []Measurement {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return append([]Measurement(nil), recorder.measurements...)
+}
+
+// Reset removes all recorded spans and measurements.
+func (recorder *Recorder) Reset() {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.spans = nil
+	recorder.measurements = nil
+}
+
+func (span *recordedSpan) SetAttributes(attributes ...transloadit.Attribute) {
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+
+	for _, attribute := range attributes {
+		span.span.Attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (span *recordedSpan) RecordError(err error) {
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+
+	span.span.Errors = append(span.span.Errors, err)
+}
+
+func (span *recordedSpan) End() {
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+
+	span.span.Ended = true
+}
+
+func attributeMap(attributes []transloadit.Attribute) map[string]interface{} {
+	m := make(map[string]interface{}, len(attributes))
+	for _, attribute := range attributes {
+		m[attribute.Key] = attribute.Value
+	}
+	return m
+}
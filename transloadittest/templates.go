@@ -0,0 +1,158 @@
+package transloadittest
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+func (server *Server) handleTemplates(method string, segments []string, params map[string]interface{}) (interface{}, *apiError) {
+	if len(segments) == 0 {
+		switch method {
+		case "GET":
+			ids := make([]string, 0, len(server.templates))
+			for id := range server.templates {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			items := make([]*template, 0, len(ids))
+			for _, id := range ids {
+				items = append(items, server.templates[id])
+			}
+			start, end := page(params, len(items))
+			return map[string]interface{}{
+				"items": items[start:end],
+				"count": len(items),
+			}, nil
+		case "POST":
+			t := &template{ID: server.newID()}
+			if apiErr := t.update(params); apiErr != nil {
+				return nil, apiErr
+			}
+			server.templates[t.ID] = t
+			return t.response("TEMPLATE_CREATED"), nil
+		}
+	} else if len(segments) == 1 {
+		t, ok := server.templates[segments[0]]
+		if !ok {
+			return nil, &apiError{http.StatusNotFound, "TEMPLATE_NOT_FOUND", "The template " + segments[0] + " does not exist."}
+		}
+
+		switch method {
+		case "GET":
+			return t.response("TEMPLATE_FOUND"), nil
+		case "PUT":
+			if apiErr := t.update(params); apiErr != nil {
+				return nil, apiErr
+			}
+			return t.response("TEMPLATE_UPDATED"), nil
+		case "DELETE":
+			delete(server.templates, t.ID)
+			return map[string]string{"ok": "TEMPLATE_DELETED"}, nil
+		}
+	}
+
+	return nil, &apiError{http.StatusNotFound, "NOT_FOUND", "Unknown templates route."}
+}
+
+func (t *template) update(params map[string]interface{}) *apiError {
+	name, _ := params["name"].(string)
+	content, _ := params["template"].(map[string]interface{})
+	if name == "" || content == nil {
+		return &apiError{http.StatusBadRequest, "TEMPLATE_INVALID", "A template requires a name and content."}
+	}
+
+	t.Name = name
+	t.Content = content
+	if require, ok := params["require_signature_auth"].(float64); ok {
+		t.RequireSignatureAuth = int(require)
+	}
+
+	return nil
+}
+
+func (t *template) response(ok string) map[string]interface{} {
+	return map[string]interface{}{
+		"ok":                     ok,
+		"id":                     t.ID,
+		"name":                   t.Name,
+		"content":                t.Content,
+		"require_signature_auth": t.RequireSignatureAuth,
+	}
+}
+
+func (server *Server) handleCredentials(method string, segments []string, params map[string]interface{}) (interface{}, *apiError) {
+	if len(segments) == 0 {
+		switch method {
+		case "GET":
+			ids := make([]string, 0, len(server.credentials))
+			for id := range server.credentials {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			items := make([]*credential, 0, len(ids))
+			for _, id := range ids {
+				items = append(items, server.credentials[id])
+			}
+			start, end := page(params, len(items))
+			return map[string]interface{}{
+				"ok":          "TEMPLATE_CREDENTIALS_FOUND",
+				"credentials": items[start:end],
+			}, nil
+		case "POST":
+			now := time.Now().UTC().Format(time.RFC3339)
+			c := &credential{ID: server.newID(), Created: now}
+			if apiErr := c.update(params, now); apiErr != nil {
+				return nil, apiErr
+			}
+			server.credentials[c.ID] = c
+			return map[string]interface{}{
+				"ok":         "TEMPLATE_CREDENTIALS_CREATED",
+				"credential": c,
+			}, nil
+		}
+	} else if len(segments) == 1 {
+		c, ok := server.credentials[segments[0]]
+		if !ok {
+			return nil, &apiError{http.StatusNotFound, "TEMPLATE_CREDENTIALS_NOT_READ", "The template credentials " + segments[0] + " do not exist."}
+		}
+
+		switch method {
+		case "GET":
+			return map[string]interface{}{
+				"ok":         "TEMPLATE_CREDENTIALS_READ",
+				"credential": c,
+			}, nil
+		case "PUT":
+			if apiErr := c.update(params, time.Now().UTC().Format(time.RFC3339)); apiErr != nil {
+				return nil, apiErr
+			}
+			return map[string]interface{}{
+				"ok":         "TEMPLATE_CREDENTIALS_UPDATED",
+				"credential": c,
+			}, nil
+		case "DELETE":
+			delete(server.credentials, c.ID)
+			return map[string]string{"ok": "TEMPLATE_CREDENTIALS_DELETED"}, nil
+		}
+	}
+
+	return nil, &apiError{http.StatusNotFound, "NOT_FOUND", "Unknown template credentials route."}
+}
+
+func (c *credential) update(params map[string]interface{}, now string) *apiError {
+	name, _ := params["name"].(string)
+	credentialType, _ := params["type"].(string)
+	content, _ := params["content"].(map[string]interface{})
+	if name == "" || credentialType == "" {
+		return &apiError{http.StatusBadRequest, "TEMPLATE_CREDENTIALS_INVALID", "Template credentials require a name and type."}
+	}
+
+	c.Name = name
+	c.Type = credentialType
+	c.Content = content
+	c.Modified = now
+	return nil
+}
@@ -0,0 +1,563 @@
+package transloadit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tusVersion = "1.0.0"
+
+// defaultTusChunkSize is used if Assembly.TusChunkSize is not set.
+const defaultTusChunkSize = 5 * 1024 * 1024
+
+// defaultTusRetryDelays is used if Assembly.TusRetryDelays is not set.
+var defaultTusRetryDelays = []time.Duration{0, time.Second, 3 * time.Second, 5 * time.Second}
+
+// tusStatusError is returned if a tus endpoint responds with an unexpected
+// status code.
+type tusStatusError struct {
+	Method     string
+	StatusCode int
+}
+
+func (err tusStatusError) Error() string {
+	return fmt.Sprintf("tus %s request failed with status code %d", err.Method, err.StatusCode)
+}
+
+// ResumeAssembly works like StartAssembly but always uploads the files using
+// the tus protocol and persists the progress of each upload in
+// Config.UploadStore. If a previous attempt to start an assembly with the same
+// instructions and files was interrupted, for example because the process was
+// terminated, the existing assembly is reused and only the bytes which have not
+// been acknowledged by the server are uploaded. The readers must be positioned
+// at the beginning of each file and their sizes must be known upfront, which is
+// the case for files added using Assembly.AddFile. The progress is only
+// discarded if the existing assembly does not exist or accept uploads anymore;
+// other errors retrieving its status are returned.
+func (client *Client) ResumeAssembly(ctx context.Context, assembly Assembly) (*AssemblyInfo, error) {
+	store := client.config.UploadStore
+	if store == nil {
+		closeReaders(assembly.readers)
+		return nil, errors.New("failed to resume assembly: Config.UploadStore is not set")
+	}
+
+	fingerprints := assembly.fingerprints()
+	records := make([]*UploadRecord, len(fingerprints))
+	var assemblyURL, tusURL string
+	for i, fingerprint := range fingerprints {
+		if fingerprint == "" {
+			closeReaders(assembly.readers)
+			return nil, fmt.Errorf("failed to resume assembly: size of %s is unknown", assembly.readers[i].Name)
+		}
+
+		record, ok, err := store.Get(fingerprint)
+		if err != nil {
+			closeReaders(assembly.readers)
+			return nil, fmt.Errorf("failed to resume assembly: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		records[i] = &record
+		if assemblyURL == "" {
+			assemblyURL = record.AssemblyURL
+			tusURL = record.TusURL
+		} else if assemblyURL != record.AssemblyURL {
+			// The files were uploaded to different assemblies, so none of them
+			// can be reused safely.
+			assemblyURL = ""
+			break
+		}
+	}
+
+	if assemblyURL != "" {
+		info, err := client.GetAssembly(ctx, assemblyURL)
+		if err != nil {
+			// Other errors, such as server errors or rate limits, may be
+			// temporary, so the records are kept for the next attempt.
+			if !errors.Is(err, ErrNotFound) {
+				closeReaders(assembly.readers)
+				return nil, err
+			}
+		} else if info.Error == "" && info.Ok == "ASSEMBLY_UPLOADING" {
+			if info.TusURL == "" {
+				info.TusURL = tusURL
+			}
+			if info.AssemblySSLURL == "" {
+				info.AssemblySSLURL = assemblyURL
+			}
+			return client.uploadTusFiles(ctx, assembly, info, fingerprints, records)
+		}
+	}
+
+	// The previous assembly is unknown or does not accept uploads anymore.
+	for _, fingerprint := range fingerprints {
+		if err := store.Delete(fingerprint); err != nil {
+			closeReaders(assembly.readers)
+			return nil, fmt.Errorf("failed to resume assembly: %w", err)
+		}
+	}
+
+	return client.startTusAssembly(ctx, assembly, fingerprints)
+}
+
+// startTusAssembly creates the assembly without uploading any files and then
+// uploads each file using the tus endpoint returned by the API. If
+// fingerprints are provided, the upload progress is saved in the upload store.
+func (client *Client) startTusAssembly(ctx context.Context, assembly Assembly, fingerprints []string) (*AssemblyInfo, error) {
+	var info AssemblyInfo
+	err := client.doRequestWithRetry(ctx, func() (*http.Request, error) {
+		params, signature, err := client.sign(assembly.options())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create assembly request: %w", err)
+		}
+
+		v := url.Values{}
+		v.Set("params", params)
+		v.Set("signature", signature)
+		v.Set("tus_num_expected_upload_files", strconv.Itoa(len(assembly.readers)))
+
+		req, err := http.NewRequest("POST", client.config.Endpoint+"/assemblies", strings.NewReader(v.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create assembly request: %w", err)
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return req, nil
+	}, &info)
+	if err != nil {
+		closeReaders(assembly.readers)
+		return nil, err
+	}
+
+	if info.Error != "" {
+		closeReaders(assembly.readers)
+		return &info, fmt.Errorf("failed to create assembly: %w", newAssemblyError(&info))
+	}
+
+	return client.uploadTusFiles(ctx, assembly, &info, fingerprints, nil)
+}
+
+// uploadTusFiles uploads the assembly's files to an existing assembly. Uploads
+// with a record from the upload store are continued instead of being started
+// from the beginning.
+func (client *Client) uploadTusFiles(ctx context.Context, assembly Assembly, info *AssemblyInfo, fingerprints []string, records []*UploadRecord) (*AssemblyInfo, error) {
+	defer closeReaders(assembly.readers)
+
+	if len(assembly.readers) == 0 {
+		return info, nil
+	}
+
+	if info.TusURL == "" {
+		return info, errors.New("failed to create assembly: response does not contain tus_url")
+	}
+
+	uploader := tusUploader{
+		client:      client,
+		endpoint:    info.TusURL,
+		assemblyURL: info.AssemblySSLURL,
+		chunkSize:   assembly.TusChunkSize,
+		retryDelays: assembly.TusRetryDelays,
+	}
+	if uploader.chunkSize <= 0 {
+		uploader.chunkSize = defaultTusChunkSize
+	}
+	if uploader.retryDelays == nil {
+		uploader.retryDelays = defaultTusRetryDelays
+	}
+	if len(fingerprints) != 0 {
+		uploader.store = client.config.UploadStore
+	}
+
+	for i, upload := range assembly.readers {
+		var fingerprint string
+		var record *UploadRecord
+		if i < len(fingerprints) {
+			fingerprint = fingerprints[i]
+		}
+		if i < len(records) {
+			record = records[i]
+		}
+
+		if err := uploader.upload(ctx, upload, fingerprint, record); err != nil {
+			return info, fmt.Errorf("failed to upload %s: %w", upload.Name, err)
+		}
+	}
+
+	// Only forget the uploads once all of them have finished. Otherwise a
+	// resumed attempt would upload completed files a second time.
+	if uploader.store != nil {
+		for _, fingerprint := range fingerprints {
+			if fingerprint == "" {
+				continue
+			}
+			if err := uploader.store.Delete(fingerprint); err != nil {
+				return info, fmt.Errorf("failed to update upload store: %w", err)
+			}
+		}
+	}
+
+	return client.GetAssembly(ctx, info.AssemblySSLURL)
+}
+
+// closeReaders closes all readers which have not been closed yet.
+func closeReaders(readers []*upload) {
+	for _, upload := range readers {
+		upload.close()
+	}
+}
+
+// tusUploader implements the client side of the tus protocol's creation
+// and core extensions. See https://tus.io/protocols/resumable-upload
+type tusUploader struct {
+	client      *Client
+	endpoint    string
+	assemblyURL string
+	chunkSize   int64
+	retryDelays []time.Duration
+	// store is optional and receives the progress of uploads which have a
+	// fingerprint.
+	store UploadStore
+}
+
+// upload sends the entire reader to a tus upload which is attached to the
+// uploader's assembly. Every chunk is kept in memory until the server has
+// acknowledged it, so an interrupted PATCH request can be resumed from the
+// offset reported by the server without seeking in the reader. If a record
+// is provided, the upload it references is continued at the offset reported
+// by the server and the bytes before it are skipped in the reader.
+func (uploader *tusUploader) upload(ctx context.Context, upload *upload, fingerprint string, record *UploadRecord) error {
+	length, known := readerSize(upload.Reader)
+	if !known {
+		length = -1
+	}
+
+	var uploadURL string
+	var offset int64
+	if record != nil && record.UploadURL != "" {
+		err := uploader.withRetry(ctx, func(attempt int) (err error) {
+			offset, err = uploader.offset(ctx, record.UploadURL)
+			return err
+		})
+
+		var statusErr tusStatusError
+		switch {
+		case err == nil:
+			uploadURL = record.UploadURL
+		case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone):
+			// The upload has expired on the server, so a new one is created.
+			offset = 0
+		default:
+			return err
+		}
+	}
+
+	if uploadURL == "" {
+		metadata := map[string]string{
+			"assembly_url": uploader.assemblyURL,
+			"fieldname":    upload.Field,
+			"filename":     upload.Name,
+		}
+
+		err := uploader.withRetry(ctx, func(attempt int) (err error) {
+			uploadURL, err = uploader.create(ctx, length, metadata)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else if offset > 0 {
+		if err := skipBytes(upload.Reader, offset); err != nil {
+			return fmt.Errorf("unable to skip acknowledged bytes: %w", err)
+		}
+	}
+
+	if err := uploader.save(fingerprint, uploadURL, offset, length); err != nil {
+		return err
+	}
+
+	buf := make([]byte, uploader.chunkSize)
+	for {
+		n, err := io.ReadFull(upload.Reader, buf)
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+
+		chunk := buf[:n]
+		chunkStart := offset
+		chunkEnd := chunkStart + int64(n)
+
+		// A known length means the server completes the upload once all bytes
+		// have arrived. Otherwise the length must be declared with the last
+		// PATCH request, even if it carries no data.
+		if n == 0 && length >= 0 {
+			break
+		}
+
+		finalLength := int64(-1)
+		if eof && length < 0 {
+			finalLength = chunkEnd
+		}
+
+		err = uploader.withRetry(ctx, func(attempt int) error {
+			if attempt > 0 {
+				serverOffset, err := uploader.offset(ctx, uploadURL)
+				if err != nil {
+					return err
+				}
+				if serverOffset < chunkStart || serverOffset > chunkEnd {
+					return fmt.Errorf("server offset %d is outside of the buffered chunk %d-%d", serverOffset, chunkStart, chunkEnd)
+				}
+				offset = serverOffset
+			}
+
+			for {
+				newOffset, err := uploader.patch(ctx, uploadURL, offset, chunk[offset-chunkStart:], finalLength)
+				if err != nil {
+					return err
+				}
+				if newOffset <= offset && offset < chunkEnd {
+					return fmt.Errorf("server did not accept any data at offset %d", offset)
+				}
+				offset = newOffset
+				if offset >= chunkEnd {
+					return nil
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := uploader.save(fingerprint, uploadURL, offset, length); err != nil {
+			return err
+		}
+
+		if eof {
+			break
+		}
+	}
+
+	return nil
+}
+
+// save records the upload's progress in the store, if one is configured.
+func (uploader *tusUploader) save(fingerprint, uploadURL string, offset, length int64) error {
+	if uploader.store == nil || fingerprint == "" {
+		return nil
+	}
+
+	err := uploader.store.Set(fingerprint, UploadRecord{
+		AssemblyURL: uploader.assemblyURL,
+		TusURL:      uploader.endpoint,
+		UploadURL:   uploadURL,
+		Offset:      offset,
+		Size:        length,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update upload store: %w", err)
+	}
+
+	return nil
+}
+
+// withRetry invokes fn until it succeeds, a non-retryable error is returned
+// or all retry delays have been used up.
+func (uploader *tusUploader) withRetry(ctx context.Context, fn func(attempt int) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || attempt >= len(uploader.retryDelays) || !isTusRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(uploader.retryDelays[attempt]):
+		}
+	}
+}
+
+// create registers a new upload and returns its URL. A negative length
+// defers the declaration of the upload's size.
+func (uploader *tusUploader) create(ctx context.Context, length int64, metadata map[string]string) (string, error) {
+	req, err := uploader.newRequest(ctx, "POST", uploader.endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if length >= 0 {
+		req.Header.Set("Upload-Length", strconv.FormatInt(length, 10))
+	} else {
+		req.Header.Set("Upload-Defer-Length", "1")
+	}
+	req.Header.Set("Upload-Metadata", encodeTusMetadata(metadata))
+
+	res, err := uploader.do(req, http.StatusCreated)
+	if err != nil {
+		return "", err
+	}
+
+	location, err := res.Location()
+	if err != nil {
+		return "", fmt.Errorf("tus POST response does not contain a valid Location header: %w", err)
+	}
+
+	return location.String(), nil
+}
+
+// offset asks the server how many bytes of the upload it has received.
+func (uploader *tusUploader) offset(ctx context.Context, uploadURL string) (int64, error) {
+	req, err := uploader.newRequest(ctx, "HEAD", uploadURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := uploader.do(req, http.StatusOK)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseTusOffset(res)
+}
+
+// patch sends data starting at offset and returns the new offset reported by
+// the server. A non-negative finalLength declares the upload's total size.
+func (uploader *tusUploader) patch(ctx context.Context, uploadURL string, offset int64, data []byte, finalLength int64) (int64, error) {
+	req, err := uploader.newRequest(ctx, "PATCH", uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	if finalLength >= 0 {
+		req.Header.Set("Upload-Length", strconv.FormatInt(finalLength, 10))
+	}
+
+	res, err := uploader.do(req, http.StatusNoContent)
+	if err != nil {
+		return 0, err
+	}
+
+	newOffset, err := parseTusOffset(res)
+	if err == nil && newOffset > offset {
+		operationFromContext(ctx).addUploaded(newOffset - offset)
+	}
+	return newOffset, err
+}
+
+func (uploader *tusUploader) newRequest(ctx context.Context, method, uri string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tus request: %w", err)
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Transloadit-Client", "go-sdk:"+Version)
+
+	return req, nil
+}
+
+// do executes the request and discards the response body, since tus
+// communicates solely using status codes and headers.
+func (uploader *tusUploader) do(req *http.Request, expectedStatus int) (*http.Response, error) {
+	res, err := uploader.client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1024*1024))
+	res.Body.Close()
+
+	if res.StatusCode != expectedStatus {
+		return nil, tusStatusError{
+			Method:     req.Method,
+			StatusCode: res.StatusCode,
+		}
+	}
+
+	return res, nil
+}
+
+func parseTusOffset(res *http.Response) (int64, error) {
+	offset, err := strconv.ParseInt(res.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("tus response contains invalid Upload-Offset header %q", res.Header.Get("Upload-Offset"))
+	}
+
+	return offset, nil
+}
+
+// isTusRetryable reports whether a failed tus request may succeed if it is
+// retried after consulting the server's offset. Transport errors and server
+// errors are considered temporary while other client errors are not.
+func isTusRetryable(err error) bool {
+	var statusErr tusStatusError
+	if !errors.As(err, &statusErr) {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusConflict, http.StatusLocked, http.StatusTooManyRequests:
+		return true
+	}
+
+	return statusErr.StatusCode >= 500
+}
+
+func encodeTusMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// skipBytes advances the reader by n bytes, seeking if possible.
+func skipBytes(reader io.Reader, n int64) error {
+	if seeker, ok := reader.(io.Seeker); ok {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		return err
+	}
+
+	_, err := io.CopyN(ioutil.Discard, reader, n)
+	return err
+}
+
+// readerSize returns the number of bytes which can be read from the reader,
+// if this can be determined without consuming it.
+func readerSize(reader io.Reader) (int64, bool) {
+	switch r := reader.(type) {
+	case *os.File:
+		stat, err := r.Stat()
+		if err != nil || !stat.Mode().IsRegular() {
+			return 0, false
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		return stat.Size() - offset, true
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,331 @@
+package transloadit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tusTestServer is a minimal stand-in for Transloadit's assembly and tus
+// endpoints. Every n-th PATCH request (if failEvery is set) only stores the
+// first half of its body and then drops the connection. If assemblyStatus is
+// set, the next request for the assembly's status fails with this status code.
+type tusTestServer struct {
+	*httptest.Server
+
+	failEvery      int
+	assemblyStatus int
+
+	mu         sync.Mutex
+	assemblies int
+	expected   int
+	patches    int
+	received   int
+	uploads    map[string]*tusTestUpload
+	order      []string
+}
+
+type tusTestUpload struct {
+	length   int64
+	metadata map[string]string
+	data     []byte
+}
+
+func newTusTestServer(t *testing.T, failEvery int) *tusTestServer {
+	server := &tusTestServer{
+		failEvery: failEvery,
+		uploads:   make(map[string]*tusTestUpload),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/assemblies":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("unable to parse form: %s", err)
+			}
+			if r.Form.Get("params") == "" || r.Form.Get("signature") == "" {
+				t.Error("params and signature must be set")
+			}
+			server.assemblies++
+			server.expected, _ = strconv.Atoi(r.Form.Get("tus_num_expected_upload_files"))
+			writeJSON(w, map[string]interface{}{
+				"ok":               "ASSEMBLY_UPLOADING",
+				"assembly_id":      "a1",
+				"assembly_ssl_url": server.URL + "/assemblies/a1",
+				"tus_url":          server.URL + "/resumable/files/",
+			})
+		case r.Method == "GET" && r.URL.Path == "/assemblies/a1" && server.assemblyStatus != 0:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(server.assemblyStatus)
+			server.assemblyStatus = 0
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "ASSEMBLY_STATUS_FAILED",
+				"message": "The assembly status could not be retrieved.",
+			})
+		case r.Method == "GET" && r.URL.Path == "/assemblies/a1":
+			status := "ASSEMBLY_EXECUTING"
+			if len(server.order) < server.expected {
+				status = "ASSEMBLY_UPLOADING"
+			}
+			uploads := make([]*FileInfo, 0, len(server.order))
+			for _, id := range server.order {
+				upload := server.uploads[id]
+				if int64(len(upload.data)) != upload.length {
+					status = "ASSEMBLY_UPLOADING"
+				}
+				uploads = append(uploads, &FileInfo{
+					Name:  upload.metadata["filename"],
+					Field: upload.metadata["fieldname"],
+					Size:  len(upload.data),
+				})
+			}
+			writeJSON(w, map[string]interface{}{
+				"ok":               status,
+				"assembly_id":      "a1",
+				"assembly_ssl_url": server.URL + "/assemblies/a1",
+				"tus_url":          server.URL + "/resumable/files/",
+				"uploads":          uploads,
+			})
+		case r.Method == "POST" && r.URL.Path == "/resumable/files/":
+			server.handleCreate(t, w, r)
+		case r.Method == "HEAD" && strings.HasPrefix(r.URL.Path, "/resumable/files/"):
+			upload, ok := server.uploads[strings.TrimPrefix(r.URL.Path, "/resumable/files/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Upload-Offset", strconv.Itoa(len(upload.data)))
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/resumable/files/"):
+			server.handlePatch(t, w, r)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func (server *tusTestServer) handleCreate(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		t.Errorf("wrong Tus-Resumable header %q", r.Header.Get("Tus-Resumable"))
+	}
+
+	upload := &tusTestUpload{
+		length:   -1,
+		metadata: make(map[string]string),
+	}
+	if r.Header.Get("Upload-Defer-Length") != "1" {
+		upload.length, _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	}
+	for _, pair := range strings.Split(r.Header.Get("Upload-Metadata"), ",") {
+		parts := strings.SplitN(pair, " ", 2)
+		value, _ := base64.StdEncoding.DecodeString(parts[1])
+		upload.metadata[parts[0]] = string(value)
+	}
+	if upload.metadata["assembly_url"] != server.URL+"/assemblies/a1" {
+		t.Errorf("wrong assembly_url metadata %q", upload.metadata["assembly_url"])
+	}
+
+	id := strconv.Itoa(len(server.uploads))
+	server.uploads[id] = upload
+	server.order = append(server.order, id)
+
+	w.Header().Set("Location", server.URL+"/resumable/files/"+id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (server *tusTestServer) handlePatch(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	upload, ok := server.uploads[strings.TrimPrefix(r.URL.Path, "/resumable/files/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset"))
+	if offset != len(upload.data) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if length := r.Header.Get("Upload-Length"); length != "" {
+		upload.length, _ = strconv.ParseInt(length, 10, 64)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Errorf("unable to read PATCH body: %s", err)
+	}
+
+	server.patches++
+	server.received += len(body)
+	if server.failEvery > 0 && server.patches%server.failEvery == 0 && len(body) > 1 {
+		upload.data = append(upload.data, body[:len(body)/2]...)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("unable to hijack connection: %s", err)
+			return
+		}
+		conn.Close()
+		return
+	}
+
+	upload.data = append(upload.data, body...)
+	if upload.length >= 0 && int64(len(upload.data)) > upload.length {
+		t.Errorf("upload exceeds declared length %d", upload.length)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.Itoa(len(upload.data)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// sizedReader exposes the Len method of the underlying reader, so the upload
+// size is known upfront.
+type sizedReader struct {
+	*bytes.Reader
+}
+
+func (sizedReader) Close() error { return nil }
+
+// unsizedReader hides the Len method of the underlying reader, so the upload
+// size cannot be determined upfront.
+type unsizedReader struct {
+	io.Reader
+}
+
+func (unsizedReader) Close() error { return nil }
+
+func testPayload(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestStartAssembly_Tus(t *testing.T) {
+	t.Parallel()
+
+	server := newTusTestServer(t, 3)
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	sized := testPayload(2500)
+	unsized := testPayload(1800)
+
+	assembly := NewAssembly()
+	assembly.UseTus = true
+	assembly.TusChunkSize = 512
+	assembly.TusRetryDelays = []time.Duration{0, 0, 0}
+	assembly.AddReader("image", "sized.bin", sizedReader{bytes.NewReader(sized)})
+	assembly.AddReader("video", "unsized.bin", unsizedReader{bytes.NewReader(unsized)})
+	assembly.AddFile("photo", "./fixtures/lol_cat.jpg")
+
+	info, err := client.StartAssembly(ctx, assembly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if info.Ok != "ASSEMBLY_EXECUTING" {
+		t.Fatalf("wrong assembly status %q", info.Ok)
+	}
+	if server.expected != 3 {
+		t.Fatalf("wrong number of expected uploads %d", server.expected)
+	}
+	if len(info.Uploads) != 3 {
+		t.Fatalf("wrong number of uploads %d", len(info.Uploads))
+	}
+
+	cat, err := ioutil.ReadFile("./fixtures/lol_cat.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]byte{
+		"image": sized,
+		"video": unsized,
+		"photo": cat,
+	}
+	for _, upload := range server.uploads {
+		field := upload.metadata["fieldname"]
+		if !bytes.Equal(upload.data, expected[field]) {
+			t.Errorf("upload for field %s has wrong content (%d bytes)", field, len(upload.data))
+		}
+		if upload.length != int64(len(expected[field])) {
+			t.Errorf("upload for field %s has wrong length %d", field, upload.length)
+		}
+	}
+}
+
+func TestStartAssembly_TusRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	server := newTusTestServer(t, 1)
+	defer server.Close()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+		Endpoint:   server.URL,
+	})
+
+	assembly := NewAssembly()
+	assembly.UseTus = true
+	assembly.TusChunkSize = 1024
+	assembly.TusRetryDelays = []time.Duration{0}
+	assembly.AddReader("image", "image.bin", sizedReader{bytes.NewReader(testPayload(1024))})
+
+	info, err := client.StartAssembly(ctx, assembly)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to upload image.bin") {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+	if info == nil || info.AssemblyID != "a1" {
+		t.Fatal("assembly info should be returned alongside the error")
+	}
+	if server.patches != 2 {
+		t.Fatalf("expected 2 PATCH requests, got %d", server.patches)
+	}
+}
+
+func TestEncodeTusMetadata(t *testing.T) {
+	t.Parallel()
+
+	encoded := encodeTusMetadata(map[string]string{"filename": "lol_cat.jpg"})
+	expected := fmt.Sprintf("filename %s", base64.StdEncoding.EncodeToString([]byte("lol_cat.jpg")))
+	if encoded != expected {
+		t.Fatalf("expected %q, got %q", expected, encoded)
+	}
+}
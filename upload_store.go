@@ -0,0 +1,239 @@
+package transloadit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// UploadStore persists the progress of resumable uploads, so that an
+// interrupted assembly can be continued using Client.ResumeAssembly, even
+// from a different process. Each upload is identified by a fingerprint which
+// is derived from the assembly instructions, the field name, the file name
+// and the file's size. Implementations must be safe for concurrent use.
+type UploadStore interface {
+	// Get returns the record for the fingerprint. The boolean is false if no
+	// record exists.
+	Get(fingerprint string) (UploadRecord, bool, error)
+	// Set creates or replaces the record for the fingerprint.
+	Set(fingerprint string, record UploadRecord) error
+	// Delete removes the record for the fingerprint. Deleting a record which
+	// does not exist is not an error.
+	Delete(fingerprint string) error
+}
+
+// UploadRecord contains the state of a single resumable upload.
+type UploadRecord struct {
+	// AssemblyURL is the URL of the assembly the file is uploaded to.
+	AssemblyURL string `json:"assembly_url"`
+	// TusURL is the tus endpoint of the assembly.
+	TusURL string `json:"tus_url"`
+	// UploadURL is the URL of the file's tus upload.
+	UploadURL string `json:"upload_url"`
+	// Offset is the number of bytes acknowledged by the server.
+	Offset int64 `json:"offset"`
+	// Size is the file's total size in bytes.
+	Size int64 `json:"size"`
+}
+
+// MemoryUploadStore is an UploadStore which keeps the records in memory. It
+// allows resuming uploads within the same process only.
+type MemoryUploadStore struct {
+	mu      sync.Mutex
+	records map[string]UploadRecord
+}
+
+// NewMemoryUploadStore returns an empty MemoryUploadStore.
+func NewMemoryUploadStore() *MemoryUploadStore {
+	return &MemoryUploadStore{
+		records: make(map[string]UploadRecord),
+	}
+}
+
+// Get implements UploadStore.
+func (store *MemoryUploadStore) Get(fingerprint string) (UploadRecord, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	record, ok := store.records[fingerprint]
+	return record, ok, nil
+}
+
+// Set implements UploadStore.
+func (store *MemoryUploadStore) Set(fingerprint string, record UploadRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.records[fingerprint] = record
+	return nil
+}
+
+// Delete implements UploadStore.
+func (store *MemoryUploadStore) Delete(fingerprint string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.records, fingerprint)
+	return nil
+}
+
+// FileUploadStore is an UploadStore which keeps the records in a JSON file,
+// allowing uploads to be resumed after the process has been restarted. The
+// file is read on every access and replaced atomically on every change. It
+// must not be shared between multiple processes at the same time.
+type FileUploadStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileUploadStore returns a FileUploadStore which is backed by the file at
+// the provided path. The file is created once the first record is saved.
+func NewFileUploadStore(path string) *FileUploadStore {
+	return &FileUploadStore{
+		path: path,
+	}
+}
+
+// Get implements UploadStore.
+func (store *FileUploadStore) Get(fingerprint string) (UploadRecord, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	records, err := store.load()
+	if err != nil {
+		return UploadRecord{}, false, err
+	}
+
+	record, ok := records[fingerprint]
+	return record, ok, nil
+}
+
+// Set implements UploadStore.
+func (store *FileUploadStore) Set(fingerprint string, record UploadRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	records, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	records[fingerprint] = record
+	return store.save(records)
+}
+
+// Delete implements UploadStore.
+func (store *FileUploadStore) Delete(fingerprint string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	records, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := records[fingerprint]; !ok {
+		return nil
+	}
+
+	delete(records, fingerprint)
+	return store.save(records)
+}
+
+func (store *FileUploadStore) load() (map[string]UploadRecord, error) {
+	records := make(map[string]UploadRecord)
+
+	content, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read upload store: %w", err)
+	}
+
+	if len(content) == 0 {
+		return records, nil
+	}
+
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("unable to parse upload store: %w", err)
+	}
+
+	return records, nil
+}
+
+func (store *FileUploadStore) save(records map[string]UploadRecord) error {
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode upload store: %w", err)
+	}
+
+	// Write to a temporary file first and rename it afterwards, so that a
+	// crash during writing does not leave a corrupted store behind.
+	file, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write upload store: %w", err)
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("unable to write upload store: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("unable to write upload store: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), store.path); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("unable to write upload store: %w", err)
+	}
+
+	return nil
+}
+
+// fingerprints returns the fingerprint for each of the assembly's readers in
+// the order they were added. The fingerprint is empty for readers whose size
+// cannot be determined, since those uploads cannot be resumed.
+func (assembly *Assembly) fingerprints() []string {
+	// encoding/json sorts map keys, so equal instructions result in the same
+	// encoding.
+	instructions, err := json.Marshal(assembly.options())
+	if err != nil {
+		instructions = nil
+	}
+
+	fingerprints := make([]string, len(assembly.readers))
+	for i, upload := range assembly.readers {
+		size, ok := readerSize(upload.Reader)
+		if !ok || instructions == nil {
+			continue
+		}
+
+		hash := sha256.New()
+		hash.Write(instructions)
+		for _, part := range []string{upload.Field, upload.Name, strconv.FormatInt(size, 10)} {
+			hash.Write([]byte{0})
+			hash.Write([]byte(part))
+		}
+
+		if file, ok := upload.Reader.(*os.File); ok {
+			if stat, err := file.Stat(); err == nil {
+				hash.Write([]byte{0})
+				hash.Write([]byte(strconv.FormatInt(stat.ModTime().UnixNano(), 10)))
+			}
+		}
+
+		fingerprints[i] = "tus-" + hex.EncodeToString(hash.Sum(nil))
+	}
+
+	return fingerprints
+}
@@ -0,0 +1,225 @@
+package transloadit
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileUploadStore(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "transloadit-upload-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "uploads.json")
+	store := NewFileUploadStore(path)
+
+	if _, ok, err := store.Get("foo"); err != nil || ok {
+		t.Fatalf("expected no record, got ok=%v err=%v", ok, err)
+	}
+
+	record := UploadRecord{
+		AssemblyURL: "https://api2.transloadit.com/assemblies/a1",
+		UploadURL:   "https://api2.transloadit.com/resumable/files/1",
+		Offset:      42,
+		Size:        100,
+	}
+	if err := store.Set("foo", record); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new instance must see the records written by the previous one.
+	got, ok, err := NewFileUploadStore(path).Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || got != record {
+		t.Fatalf("wrong record %+v", got)
+	}
+
+	if err := store.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Get("foo"); ok {
+		t.Fatal("record should have been deleted")
+	}
+}
+
+func TestResumeAssembly(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "transloadit-upload-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Every fourth PATCH request fails. Since no retries are allowed, the
+	// first attempt is interrupted after three chunks of the first file.
+	server := newTusTestServer(t, 4)
+	defer server.Close()
+
+	first := testPayload(3000)
+	second := testPayload(2000)
+	newAssembly := func() Assembly {
+		assembly := NewAssembly()
+		assembly.TusChunkSize = 512
+		assembly.TusRetryDelays = []time.Duration{}
+		assembly.AddStep("resize", map[string]interface{}{
+			"robot": "/image/resize",
+		})
+		assembly.AddReader("first", "first.bin", sizedReader{bytes.NewReader(first)})
+		assembly.AddReader("second", "second.bin", sizedReader{bytes.NewReader(second)})
+		return assembly
+	}
+	newClient := func() Client {
+		return NewClient(Config{
+			AuthKey:     "key",
+			AuthSecret:  "secret",
+			Endpoint:    server.URL,
+			UploadStore: NewFileUploadStore(filepath.Join(dir, "uploads.json")),
+		})
+	}
+
+	client := newClient()
+	if _, err := client.ResumeAssembly(ctx, newAssembly()); err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+
+	server.mu.Lock()
+	server.failEvery = 0
+	receivedBefore := server.received
+	server.mu.Unlock()
+
+	// Simulate a restarted process by using a new client and store instance.
+	client = newClient()
+	info, err := client.ResumeAssembly(ctx, newAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if info.Ok != "ASSEMBLY_EXECUTING" {
+		t.Fatalf("wrong assembly status %q", info.Ok)
+	}
+	if server.assemblies != 1 {
+		t.Fatalf("expected 1 assembly to be created, got %d", server.assemblies)
+	}
+	if len(server.uploads) != 2 {
+		t.Fatalf("expected 2 uploads, got %d", len(server.uploads))
+	}
+
+	expected := map[string][]byte{
+		"first":  first,
+		"second": second,
+	}
+	for _, upload := range server.uploads {
+		field := upload.metadata["fieldname"]
+		if !bytes.Equal(upload.data, expected[field]) {
+			t.Errorf("upload for field %s has wrong content (%d bytes)", field, len(upload.data))
+		}
+	}
+
+	// The first attempt stored 3*512 bytes plus half of the fourth chunk.
+	resent := server.received - receivedBefore
+	if resent != len(first)-1792+len(second) {
+		t.Fatalf("expected only missing bytes to be sent, got %d bytes", resent)
+	}
+
+	records, err := client.config.UploadStore.(*FileUploadStore).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("expected upload store to be empty, got %d records", len(records))
+	}
+}
+
+func TestResumeAssembly_StatusError(t *testing.T) {
+	t.Parallel()
+
+	server := newTusTestServer(t, 0)
+	defer server.Close()
+	server.assemblyStatus = http.StatusInternalServerError
+
+	store := NewMemoryUploadStore()
+	client := NewClient(Config{
+		AuthKey:     "key",
+		AuthSecret:  "secret",
+		Endpoint:    server.URL,
+		UploadStore: store,
+	})
+
+	newAssembly := func() Assembly {
+		assembly := NewAssembly()
+		assembly.AddReader("file", "file.bin", sizedReader{bytes.NewReader(testPayload(1000))})
+		return assembly
+	}
+
+	// Simulate an interrupted upload to the existing assembly.
+	assembly := newAssembly()
+	fingerprint := assembly.fingerprints()[0]
+	record := UploadRecord{
+		AssemblyURL: server.URL + "/assemblies/a1",
+		TusURL:      server.URL + "/resumable/files/",
+		UploadURL:   server.URL + "/resumable/files/u1",
+		Offset:      500,
+	}
+	if err := store.Set(fingerprint, record); err != nil {
+		t.Fatal(err)
+	}
+
+	// A server error does not mean that the assembly is gone.
+	_, err := client.ResumeAssembly(ctx, newAssembly())
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok, _ := store.Get(fingerprint); !ok {
+		t.Fatal("expected the record to be kept")
+	}
+
+	// An unknown assembly is replaced by a new one.
+	server.mu.Lock()
+	server.assemblyStatus = http.StatusNotFound
+	server.mu.Unlock()
+
+	if _, err := client.ResumeAssembly(ctx, newAssembly()); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.assemblies != 1 {
+		t.Fatalf("expected 1 assembly to be created, got %d", server.assemblies)
+	}
+}
+
+func TestResumeAssembly_MissingStore(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(Config{
+		AuthKey:    "key",
+		AuthSecret: "secret",
+	})
+
+	_, err := client.ResumeAssembly(ctx, NewAssembly())
+	if err == nil || !strings.Contains(err.Error(), "UploadStore") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
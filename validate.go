@@ -0,0 +1,259 @@
+package transloadit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError describes a problem with a single step of the assembly
+// instructions which would be rejected by the API.
+type ValidationError struct {
+	// Step is the name of the affected step.
+	Step string
+	// Code identifies the kind of problem, for example STEP_USE_NOT_FOUND.
+	Code    string
+	Message string
+}
+
+// Error returns a formatted message describing the error.
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("step %s is invalid due to %s: %s", err.Step, err.Code, err.Message)
+}
+
+// ValidationErrors is returned by Assembly.Validate and
+// TemplateContent.Validate and contains all problems found, sorted by step
+// name.
+type ValidationErrors []ValidationError
+
+// Error returns the messages of all errors.
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// originalStep is the name referring to the uploaded files in `use`.
+const originalStep = ":original"
+
+// knownRobots contains the names of all robots offered by the API.
+// See https://transloadit.com/docs/transcoding/
+var knownRobots = map[string]bool{
+	"/assembly/savejson": true, "/audio/artwork": true, "/audio/concat": true,
+	"/audio/encode": true, "/audio/loop": true, "/audio/merge": true,
+	"/audio/waveform": true, "/azure/import": true, "/azure/store": true,
+	"/backblaze/import": true, "/backblaze/store": true, "/cloudfiles/import": true,
+	"/cloudfiles/store": true, "/cloudflare/import": true, "/cloudflare/store": true,
+	"/digitalocean/import": true, "/digitalocean/store": true, "/document/autorotate": true,
+	"/document/convert": true, "/document/merge": true, "/document/ocr": true,
+	"/document/split": true, "/document/thumbs": true, "/dropbox/import": true,
+	"/dropbox/store": true, "/edgly/deliver": true, "/file/compress": true,
+	"/file/decompress": true, "/file/filter": true, "/file/hash": true,
+	"/file/preview": true, "/file/read": true, "/file/serve": true,
+	"/file/verify": true, "/file/virusscan": true, "/file/watermark": true,
+	"/ftp/import": true, "/ftp/store": true, "/google/import": true,
+	"/google/store": true, "/html/convert": true, "/http/import": true,
+	"/image/bgremove": true, "/image/describe": true, "/image/facedetect": true,
+	"/image/generate": true, "/image/merge": true, "/image/ocr": true,
+	"/image/optimize": true, "/image/resize": true, "/meta/write": true,
+	"/minio/import": true, "/minio/store": true, "/progress/simulate": true,
+	"/s3/import": true, "/s3/store": true, "/script/run": true,
+	"/sftp/import": true, "/sftp/store": true, "/speech/transcribe": true,
+	"/supabase/import": true, "/supabase/store": true, "/swift/import": true,
+	"/swift/store": true, "/text/speak": true, "/text/translate": true,
+	"/tigris/import": true, "/tigris/store": true, "/tlcdn/deliver": true,
+	"/upload/handle": true, "/video/adaptive": true, "/video/concat": true,
+	"/video/encode": true, "/video/merge": true, "/video/ondemand": true,
+	"/video/subtitle": true, "/video/thumbs": true, "/vimeo/import": true,
+	"/vimeo/store": true, "/wasabi/import": true, "/wasabi/store": true,
+	"/youtube/store": true,
+}
+
+// Validate checks the steps added using AddStep without contacting the API.
+// It returns ValidationErrors if a step is invalid, see
+// TemplateContent.Validate for the performed checks. If TemplateID is set,
+// the steps may only override parameters of the template's steps, so missing
+// robots and `use` targets are not reported.
+func (assembly *Assembly) Validate() error {
+	steps := make(map[string]interface{}, len(assembly.steps))
+	for name, step := range assembly.steps {
+		steps[name] = step
+	}
+	return validateSteps(steps, assembly.TemplateID == "")
+}
+
+// Validate checks the steps without contacting the API. It returns
+// ValidationErrors if
+//
+//   - a step name is empty,
+//   - a step is not an object,
+//   - a step has no robot or a robot which is not known,
+//   - `use` is malformed or refers to a step which does not exist and is
+//     not :original,
+//   - the steps form a cycle via `use`, or
+//   - `result` is not a boolean.
+func (content TemplateContent) Validate() error {
+	return validateSteps(content.Steps, true)
+}
+
+// validateSteps checks the steps. If complete is false, the steps are merged
+// with those of a template, so robots and used steps may be missing.
+func validateSteps(steps map[string]interface{}, complete bool) error {
+	var errs ValidationErrors
+	uses := make(map[string][]string, len(steps))
+
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// The API accepts any other name, including those containing slashes
+		// such as image/resize and reserved ones such as :original.
+		if name == "" {
+			errs = append(errs, ValidationError{name, "INVALID_STEP_NAME", "step name must not be empty"})
+		}
+
+		step, ok := steps[name].(map[string]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{name, "STEP_INVALID", "step is not an object"})
+			continue
+		}
+
+		robot, ok := step["robot"].(string)
+		switch {
+		case !ok && !complete:
+			// The robot is defined by the template's step.
+		case !ok || robot == "":
+			errs = append(errs, ValidationError{name, "STEP_ROBOT_MISSING", "robot is missing"})
+		case !knownRobots[robot]:
+			errs = append(errs, ValidationError{name, "STEP_ROBOT_UNKNOWN", fmt.Sprintf("robot %s does not exist", robot)})
+		}
+
+		if result, ok := step["result"]; ok {
+			if _, ok := result.(bool); !ok {
+				errs = append(errs, ValidationError{name, "STEP_RESULT_INVALID", fmt.Sprintf("result must be a boolean but is %v", result)})
+			}
+		}
+
+		stepUses, err := parseUse(step["use"])
+		if err != nil {
+			errs = append(errs, ValidationError{name, "STEP_USE_INVALID", err.Error()})
+			continue
+		}
+		uses[name] = stepUses
+
+		for _, use := range stepUses {
+			if _, ok := steps[use]; !ok && use != originalStep && complete {
+				errs = append(errs, ValidationError{name, "STEP_USE_NOT_FOUND", fmt.Sprintf("used step %s does not exist", use)})
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(names, uses) {
+		errs = append(errs, ValidationError{cycle[0], "STEP_USE_CYCLE", "steps form a cycle: " + strings.Join(cycle, " -> ")})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Step < errs[j].Step
+	})
+	return errs
+}
+
+// parseUse returns the names of the steps referenced by the `use` parameter,
+// which is either a step name, a list of step names or an object with a list
+// of steps, each being a name or an object with a name.
+// See https://transloadit.com/docs/topics/use-parameter/
+func parseUse(use interface{}) ([]string, error) {
+	switch use := use.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{use}, nil
+	case []string:
+		return use, nil
+	case []interface{}:
+		names := make([]string, 0, len(use))
+		for _, item := range use {
+			switch item := item.(type) {
+			case string:
+				names = append(names, item)
+			case map[string]interface{}:
+				name, ok := item["name"].(string)
+				if !ok {
+					return nil, fmt.Errorf("use entry %v has no name", item)
+				}
+				names = append(names, name)
+			default:
+				return nil, fmt.Errorf("use entry %v is neither a name nor an object", item)
+			}
+		}
+		return names, nil
+	case map[string]interface{}:
+		steps, ok := use["steps"]
+		if !ok {
+			return nil, fmt.Errorf("use object has no steps")
+		}
+		if _, ok := steps.(map[string]interface{}); ok {
+			return nil, fmt.Errorf("use steps must be a list")
+		}
+		return parseUse(steps)
+	default:
+		return nil, fmt.Errorf("use %v is neither a name, a list nor an object", use)
+	}
+}
+
+// findCycles returns each cycle in the graph of steps once, starting and
+// ending with the same step. The nodes are visited in the provided order.
+func findCycles(names []string, uses map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var cycles [][]string
+	state := make(map[string]int, len(names))
+	var path []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, use := range uses[name] {
+			switch state[use] {
+			case unvisited:
+				if _, ok := uses[use]; ok {
+					visit(use)
+				}
+			case visiting:
+				for i := range path {
+					if path[i] == use {
+						cycle := append(append([]string(nil), path[i:]...), use)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+
+	return cycles
+}
@@ -0,0 +1,108 @@
+package transloadit
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateContent_Validate(t *testing.T) {
+	t.Parallel()
+
+	var content TemplateContent
+	err := json.Unmarshal([]byte(`{
+		"steps": {
+			":original": {"robot": "/upload/handle"},
+			"resize": {"robot": "/image/resize", "use": ":original", "result": true},
+			"optimize": {"robot": "/image/optimize", "use": ["resize", {"name": "missing"}]},
+			"store": {"robot": "/s3/store", "use": {"steps": ["optimize", "resize"], "bundle_steps": true}},
+			"encode": {"robot": "/video/encode", "use": "thumbs", "result": "yes"},
+			"thumbs": {"robot": "/video/thumbs", "use": "encode"},
+			"import": {"use": 42},
+			"watermark": {"robot": "/image/watermark"},
+			"filter": "not an object",
+			"image/resize": {"robot": "/image/resize", "use": ":original"},
+			"": {"robot": "/file/filter"}
+		}
+	}`), &content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs ValidationErrors
+	if !errors.As(content.Validate(), &errs) {
+		t.Fatal("expected ValidationErrors")
+	}
+
+	expected := ValidationErrors{
+		{"", "INVALID_STEP_NAME", "step name must not be empty"},
+		{"encode", "STEP_RESULT_INVALID", "result must be a boolean but is yes"},
+		{"encode", "STEP_USE_CYCLE", "steps form a cycle: encode -> thumbs -> encode"},
+		{"filter", "STEP_INVALID", "step is not an object"},
+		{"import", "STEP_ROBOT_MISSING", "robot is missing"},
+		{"import", "STEP_USE_INVALID", "use 42 is neither a name, a list nor an object"},
+		{"optimize", "STEP_USE_NOT_FOUND", "used step missing does not exist"},
+		{"watermark", "STEP_ROBOT_UNKNOWN", "robot /image/watermark does not exist"},
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		for _, err := range errs {
+			t.Log(err)
+		}
+		t.Fatal("wrong validation errors")
+	}
+}
+
+func TestAssembly_Validate(t *testing.T) {
+	t.Parallel()
+
+	// Step names may contain slashes, as used by setupTemplates.
+	assembly := NewAssembly()
+	assembly.AddStep("image/resize", map[string]interface{}{
+		"robot": "/image/resize",
+		"use":   ":original",
+	})
+	assembly.AddStep("store", map[string]interface{}{
+		"robot": "/s3/store",
+		"use":   []string{"image/resize", "template_step"},
+	})
+
+	err := assembly.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Code != "STEP_USE_NOT_FOUND" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Steps may be defined by the template.
+	assembly.TemplateID = "template"
+	if err := assembly.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssembly_ValidateTemplateOverride(t *testing.T) {
+	t.Parallel()
+
+	// Steps overriding a template's steps usually only contain parameters.
+	assembly := NewAssembly()
+	assembly.TemplateID = "template"
+	assembly.AddStep("resize", map[string]interface{}{"width": 200})
+	if err := assembly.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Robots which are given must still exist.
+	assembly.AddStep("watermark", map[string]interface{}{"robot": "/image/watermark"})
+	err := assembly.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Code != "STEP_ROBOT_UNKNOWN" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Without a template, the robot is required.
+	assembly.TemplateID = ""
+	err = assembly.Validate()
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Code != "STEP_ROBOT_MISSING" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -5,31 +5,140 @@ import (
 	"time"
 )
 
+// WaitOptions configures how WaitForAssemblyWithOptions polls the assembly
+// status.
+type WaitOptions struct {
+	// Interval is the delay before the status is fetched again for the first
+	// time. Defaults to one second.
+	Interval time.Duration
+	// Multiplier is applied to the delay after each poll to back off
+	// gradually. Values below 1 keep the delay constant.
+	Multiplier float64
+	// MaxInterval caps the delay between two polls. Zero means no cap.
+	MaxInterval time.Duration
+	// Timeout is the overall deadline for waiting, after which
+	// context.DeadlineExceeded is returned. Zero means no deadline besides
+	// the one of the supplied context.
+	Timeout time.Duration
+	// IsDone reports whether polling can stop for the fetched status. If it
+	// is nil, AssemblyFinished is used.
+	IsDone func(info *AssemblyInfo) bool
+}
+
+// DefaultWaitOptions polls the assembly status once per second.
+var DefaultWaitOptions = WaitOptions{
+	Interval:   time.Second,
+	Multiplier: 1,
+}
+
+// AssemblyFinished reports whether the assembly has entered an error state or
+// is no longer uploading, executing or replaying. This includes assemblies
+// which have been aborted (REQUEST_ABORTED) or canceled.
+func AssemblyFinished(info *AssemblyInfo) bool {
+	if info.Error != "" {
+		return true
+	}
+
+	switch info.Ok {
+	case "ASSEMBLY_UPLOADING", "ASSEMBLY_EXECUTING", "ASSEMBLY_REPLAYING":
+		return false
+	}
+	return true
+}
+
 // WaitForAssembly fetches continuously the assembly status until it has
 // finished uploading and executing or until an assembly error occurs.
 // If you want to end this loop prematurely, you can cancel the supplied context.
 func (client *Client) WaitForAssembly(ctx context.Context, assembly *AssemblyInfo) (*AssemblyInfo, error) {
+	return client.WaitForAssemblyWithOptions(ctx, assembly, DefaultWaitOptions)
+}
+
+// WaitForAssemblyWithOptions fetches continuously the assembly status, just
+// like WaitForAssembly, but allows the polling interval, backoff and
+// completion criteria to be configured.
+func (client *Client) WaitForAssemblyWithOptions(ctx context.Context, assembly *AssemblyInfo, options WaitOptions) (*AssemblyInfo, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	isDone := options.IsDone
+	if isDone == nil {
+		isDone = AssemblyFinished
+	}
+
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultWaitOptions.Interval
+	}
+
 	for {
 		res, err := client.GetAssembly(ctx, assembly.AssemblySSLURL)
 		if err != nil {
 			return nil, err
 		}
 
-		// Abort polling if the assembly has entered an error state
-		if res.Error != "" {
-			return res, nil
-		}
-
-		// The polling is done if the assembly is not uploading or executing anymore.
-		if res.Ok != "ASSEMBLY_UPLOADING" && res.Ok != "ASSEMBLY_EXECUTING" {
+		if isDone(res) {
 			return res, nil
 		}
 
+		timer := time.NewTimer(interval)
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			return nil, ctx.Err()
-		case <-time.After(time.Second):
-			continue
+		case <-timer.C:
+		}
+
+		interval = nextWaitInterval(interval, options)
+	}
+}
+
+// nextWaitInterval applies the backoff configured in options to interval.
+func nextWaitInterval(interval time.Duration, options WaitOptions) time.Duration {
+	if options.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * options.Multiplier)
+	}
+	if options.MaxInterval > 0 && interval > options.MaxInterval {
+		interval = options.MaxInterval
+	}
+	return interval
+}
+
+// ResultHandlerFunc is invoked by WatchAssembly for every result file. If it
+// returns an error, watching the assembly is stopped and the error is
+// returned by WatchAssembly.
+type ResultHandlerFunc func(step string, file *FileInfo) error
+
+// WatchAssembly waits until the assembly has finished uploading and executing
+// or until an assembly error occurs, just like WaitForAssembly. In addition,
+// onResult is invoked each time a new file appears in AssemblyInfo.Results,
+// allowing early results, such as thumbnails, to be used before the entire
+// assembly has finished. Each file is reported once per step, identified by
+// its FileInfo.ID. The updates are received using StreamAssembly.
+// If you want to end this loop prematurely, you can cancel the supplied context.
+func (client *Client) WatchAssembly(ctx context.Context, assembly *AssemblyInfo, onResult ResultHandlerFunc) (*AssemblyInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for event := range client.StreamAssembly(ctx, assembly) {
+		switch event.Type {
+		case EventResultFinished:
+			if err := onResult(event.Step, event.File); err != nil {
+				return nil, err
+			}
+		case EventFinished:
+			return event.Info, nil
+		case EventError:
+			// Assembly errors are reported using AssemblyInfo.Error, as done by
+			// WaitForAssembly.
+			if event.Info != nil {
+				return event.Info, nil
+			}
+			return nil, event.Err
 		}
 	}
+
+	return nil, ctx.Err()
 }
@@ -0,0 +1,184 @@
+package transloadit_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	transloadit "github.com/transloadit/go-sdk"
+	"github.com/transloadit/go-sdk/transloadittest"
+)
+
+func TestWatchAssembly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(transloadittest.StateUploading, transloadittest.StateExecuting, transloadittest.StateCompleted)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddResult(info.AssemblyID, "thumbs", transloadit.FileInfo{ID: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	id := info.AssemblyID
+	var results []string
+	info, err = client.WatchAssembly(ctx, info, func(step string, file *transloadit.FileInfo) error {
+		// The first result is reported while the assembly is executing, so
+		// the remaining results are added afterwards.
+		if len(results) == 0 {
+			if status, _ := server.Assembly(id); status.Ok != "ASSEMBLY_EXECUTING" {
+				t.Errorf("first result reported in state %s", status.Ok)
+			}
+			if err := server.AddResult(id, "thumbs", transloadit.FileInfo{ID: "t2"}); err != nil {
+				t.Error(err)
+			}
+			if err := server.AddResult(id, "filter", transloadit.FileInfo{ID: "t1"}); err != nil {
+				t.Error(err)
+			}
+		}
+
+		results = append(results, step+"/"+file.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Ok != "ASSEMBLY_COMPLETED" {
+		t.Fatalf("wrong status %q", info.Ok)
+	}
+
+	if len(results) != 3 || results[0] != "thumbs/t1" {
+		t.Fatalf("wrong results %v", results)
+	}
+	sort.Strings(results[1:])
+	if results[1] != "filter/t1" || results[2] != "thumbs/t2" {
+		t.Fatalf("wrong results %v", results)
+	}
+}
+
+func TestWatchAssembly_HandlerError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(transloadittest.StateExecuting)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddResult(info.AssemblyID, "thumbs", transloadit.FileInfo{ID: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	handlerErr := errors.New("publishing failed")
+	_, err = client.WatchAssembly(ctx, info, func(step string, file *transloadit.FileInfo) error {
+		return handlerErr
+	})
+	if err != handlerErr {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestWaitForAssemblyWithOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(
+		transloadittest.StateReplaying,
+		transloadittest.StateExecuting,
+		transloadittest.AssemblyState{Ok: "REQUEST_ABORTED"},
+	)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = client.WaitForAssemblyWithOptions(ctx, info, transloadit.WaitOptions{
+		Interval:    time.Millisecond,
+		Multiplier:  2,
+		MaxInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Ok != "REQUEST_ABORTED" {
+		t.Fatalf("wrong status %q", info.Ok)
+	}
+}
+
+func TestWaitForAssemblyWithOptions_IsDone(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(
+		transloadittest.StateUploading,
+		transloadittest.StateUploading,
+		transloadittest.StateExecuting,
+		transloadittest.StateCompleted,
+	)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = client.WaitForAssemblyWithOptions(ctx, info, transloadit.WaitOptions{
+		Interval: time.Millisecond,
+		IsDone: func(info *transloadit.AssemblyInfo) bool {
+			return info.Ok != "ASSEMBLY_UPLOADING"
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Ok != "ASSEMBLY_EXECUTING" {
+		t.Fatalf("wrong status %q", info.Ok)
+	}
+}
+
+func TestWaitForAssemblyWithOptions_Timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := transloadittest.NewServer("key", "secret")
+	defer server.Close()
+
+	server.SetTransitions(transloadittest.StateExecuting)
+
+	client := transloadit.NewClient(server.Config())
+	info, err := client.StartAssembly(ctx, transloadit.NewAssembly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.WaitForAssemblyWithOptions(ctx, info, transloadit.WaitOptions{
+		Interval: time.Hour,
+		Timeout:  50 * time.Millisecond,
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -60,3 +60,22 @@ func TestWaitForAssembly_Cancel(t *testing.T) {
 		t.Fatalf("operation's deadline should be exceeded: %s", err)
 	}
 }
+
+func TestNextWaitInterval(t *testing.T) {
+	t.Parallel()
+
+	options := WaitOptions{Multiplier: 2, MaxInterval: 3 * time.Second}
+	expected := []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second}
+
+	interval := time.Second
+	for _, want := range expected {
+		interval = nextWaitInterval(interval, options)
+		if interval != want {
+			t.Fatalf("expected interval %s, got %s", want, interval)
+		}
+	}
+
+	if interval := nextWaitInterval(time.Second, DefaultWaitOptions); interval != time.Second {
+		t.Fatalf("default interval should be constant, got %s", interval)
+	}
+}